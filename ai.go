@@ -1,60 +1,178 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"math/rand"
+	"sync"
 )
 
 type ScoreTable []map[Idx]int
 
 type AiPlayer struct {
-	N             int
+	N             int // the longer side of the board, the length of the lines
+	W             int // width of the board
+	H             int // height of the board
 	Colour        Turn
 	depth         int
 	evalCount     int
 	ScoreTable    ScoreTable // store the pre-calculated score for each row
 	EndScoreTable ScoreTable // store the pre-calculated score for each row
+
+	patterns *patternEvaluator // scores the lines instead of ScoreTable, if not nil
+
+	Threads int // root moves searched at the same time. 1 or less searches them one by one
+
+	killers    [][2]int // moves that caused a cutoff, for each depth
+	history    [2][]int // how often each cell caused a cutoff, for each colour
+	plainOrder bool     // searches the moves in the cell order, to compare the number of nodes with the ordering
+
+	ctx context.Context // stops the search when it's cancelled, if not nil
 }
 
+const (
+	MIN_AI_LEVEL     = 1
+	MAX_AI_LEVEL     = 5
+	DEFAULT_AI_LEVEL = MAX_AI_LEVEL
+)
+
+// search depth for each AI level. 0 places randomly
+var aiLevelDepths = [MAX_AI_LEVEL + 1]int{1: 0, 2: 1, 3: 3, 4: 5, 5: 7}
+
 func NewAiPlayer(n int) *AiPlayer {
-	ap := AiPlayer{N: n, depth: 7}
+	return NewAiPlayerWithLevel(n, DEFAULT_AI_LEVEL)
+}
+
+func NewAiPlayerWithLevel(n int, level int) *AiPlayer {
+	return NewRectAiPlayer(n, n, level)
+}
+
+// NewRectAiPlayer returns the AI for a board of w x h cells
+func NewRectAiPlayer(w, h int, level int) *AiPlayer {
+	ap := AiPlayer{N: max(w, h), W: w, H: h, depth: aiLevelDepths[level]}
 
-	ap.calcScoreTable()
+	ap.calcScoreTable(cellScores(w, h))
+
+	// the patterns are learned only on square boards
+	if pt, ok := aiPatterns[w]; ok && w == h {
+		ap.patterns = newPatternEvaluator(w, pt)
+	}
 
 	return &ap
 }
 
-func (ap *AiPlayer) calcScoreTable() {
-	var cellScore [][]int
+// aiPlayers keeps the strongest AI of each board size, as the tables of a large board take long to build.
+// The rules don't change the tables, so the size is the key
+var aiPlayers = struct {
+	sync.Mutex
+	bySize map[[2]int]*AiPlayer
+}{bySize: make(map[[2]int]*AiPlayer)}
+
+// newAiPlayerFor returns the strongest AI for the size of the board.
+// It's a copy of the kept one, sharing the tables like the workers of the parallel search
+func newAiPlayerFor(b *Board) *AiPlayer {
+	aiPlayers.Lock()
+	defer aiPlayers.Unlock()
+
+	key := [2]int{b.W, b.H}
+	kept, ok := aiPlayers.bySize[key]
+	if !ok {
+		kept = NewRectAiPlayer(b.W, b.H, DEFAULT_AI_LEVEL)
+		aiPlayers.bySize[key] = kept
+	}
+
+	ap := *kept
+	return &ap
+}
+
+// resetAiPlayers drops the kept AIs, so that the next ones are built with the current weights
+func resetAiPlayers() {
+	aiPlayers.Lock()
+	defer aiPlayers.Unlock()
+
+	clear(aiPlayers.bySize)
+}
+
+// cellScores returns the score of each cell for the board size, from the weights file if it has the size.
+// The sizes without a table, e.g., rectangles, get a generated table
+func cellScores(w, h int) [][]int {
+	if w != h {
+		return generateCellScores(w, h)
+	}
 
-	scoreTable := make(ScoreTable, ap.N)
-	endScoreTable := make(ScoreTable, ap.N)
+	n := w
 
-	switch ap.N {
+	if table, ok := aiWeights[n]; ok {
+		return table
+	}
+
+	switch n {
 	case 3:
-		cellScore = cellScore3
+		return cellScore3
 	case 4:
-		cellScore = cellScore4
+		return cellScore4
 	case 5:
-		cellScore = cellScore5
+		return cellScore5
 	case 6:
-		cellScore = cellScore6
+		return cellScore6
 	case 7:
-		cellScore = cellScore7
+		return cellScore7
 	case 8:
-		cellScore = cellScore8
+		return cellScore8
+	default:
+		return generateCellScores(w, h)
+	}
+}
+
+// generateCellScores makes the cell scores for any board size like the tables of the square boards:
+// corners are the best, and the cells next to them are the worst
+func generateCellScores(w, h int) [][]int {
+	// the distance from the nearest edge, and from the nearest corner along the edge
+	distance := func(i, n int) int {
+		return min(i, n-1-i)
 	}
 
-	idxN := pow(3, ap.N)
+	table := make([][]int, h)
+	for y := range table {
+		table[y] = make([]int, w)
+		for x := range table[y] {
+			dx, dy := distance(x, w), distance(y, h)
+
+			switch {
+			case dx == 0 && dy == 0: // corner
+				table[y][x] = 30
+			case dx == 1 && dy == 1: // X-square
+				table[y][x] = -15
+			case dx+dy == 1: // C-square
+				table[y][x] = -12
+			case dx == 0 || dy == 0: // edge
+				table[y][x] = 0
+			case dx == 1 || dy == 1: // next to an edge
+				table[y][x] = -3
+			default:
+				table[y][x] = -1
+			}
+		}
+	}
+
+	return table
+}
+
+func (ap *AiPlayer) calcScoreTable(cellScore [][]int) {
+	scoreTable := make(ScoreTable, ap.H)
+	endScoreTable := make(ScoreTable, ap.H)
 
-	for row := 0; row < ap.N; row++ {
+	// the cells after the width are always empty
+	idxN := pow(3, ap.W)
+
+	for row := 0; row < ap.H; row++ {
 		scoreTable[row] = make(map[Idx]int)
 		endScoreTable[row] = make(map[Idx]int)
 		for idx := 0; idx < idxN; idx++ {
 			score := 0
 			endScore := 0
 
-			for local := 0; local < ap.N; local++ {
+			for local := 0; local < ap.W; local++ {
 				localState := idx / pow(3, local) % 3
 				if localState == 1 {
 					// add score if cell is black
@@ -77,15 +195,26 @@ func (ap *AiPlayer) calcScoreTable() {
 
 func (ap *AiPlayer) getPosition(b *Board) Position {
 	ap.Colour = b.Turn
-	return ap.getBest(b)
+
+	if ap.depth == 0 {
+		return ap.getRandom(b)
+	}
+
+	p, pv := ap.getBest(b)
+	logger.Debug("pv    ", slog.String("moves", formatPV(pv)))
+
+	return p
 }
 
-// place randomly
+// getBest returns the best move and the principal variation, starting with the move
+func (ap *AiPlayer) getBest(b *Board) (Position, []Move) {
+	if ap.Threads > 1 {
+		return ap.getBestParallel(b)
+	}
 
-func (ap *AiPlayer) getBest(b *Board) Position {
 	ap.evalCount = 0
+	ap.resetOrdering(ap.depth)
 	depth := ap.depth
-	bestCell := 0
 	alpha := -9999
 	beta := 9999
 
@@ -94,20 +223,37 @@ func (ap *AiPlayer) getBest(b *Board) Position {
 	canUseFinal := b.CountEmptyCells() < depth
 	// canUseFinal = true
 
-	for cell := 0; cell < b.CellN; cell++ {
-		if !b.IsLegal(cell, b.Turn) {
-			continue
+	moves := ap.rootMoves(b)
+	bestCell, bestPV := firstMove(b, moves)
+
+	for i, m := range moves {
+		if ap.cancelled() {
+			break
 		}
 
-		// evaluate the current board
-		b, _ := b.Place(ap.cellToPosition(cell))
+		placed := m.place(b)
+		var pv []Move
 
-		score = -ap.negMax(b, depth-1, -beta, -alpha, false, canUseFinal)
+		if i == 0 {
+			score = -ap.negMax(placed, depth-1, -beta, -alpha, false, canUseFinal, &pv)
+		} else {
+			// a null window only tells if the move is better than the best so far
+			score = -ap.negMax(placed, depth-1, -alpha-1, -alpha, false, canUseFinal, &pv)
+			if score > alpha {
+				score = -ap.negMax(placed, depth-1, -beta, -alpha, false, canUseFinal, &pv)
+			}
+		}
 
-		logger.Debug("point ", slog.Any("cell", ap.cellToPosition(cell)), slog.Int("score", score))
+		// the score of a move cut off by the cancel is meaningless
+		if ap.cancelled() {
+			break
+		}
+
+		logger.Debug("point ", slog.Any("cell", ap.cellToPosition(m.cell)), slog.Int("score", score))
 
 		if score > alpha {
-			bestCell = cell
+			bestCell = m.cell
+			bestPV = append([]Move{{Colour: b.Turn, Position: ap.cellToPosition(m.cell)}}, pv...)
 			alpha = score
 		}
 	}
@@ -115,10 +261,22 @@ func (ap *AiPlayer) getBest(b *Board) Position {
 	logger.Debug("best  ", slog.Any("cell", ap.cellToPosition(bestCell)), slog.Int("score", alpha))
 
 	logger.Debug("evaluated ", slog.Int("evalCount", ap.evalCount))
-	return ap.cellToPosition(bestCell)
+	return ap.cellToPosition(bestCell), bestPV
+}
+
+// firstMove returns the first of the root moves with its principal variation, which is the best move
+// until a search finishes, so that a search cancelled early still returns a legal move
+func firstMove(b *Board, moves []orderedMove) (int, []Move) {
+	if len(moves) == 0 {
+		return 0, nil
+	}
+
+	return moves[0].cell, []Move{{Colour: b.Turn, Position: cellToPosition(b.W, moves[0].cell)}}
 }
 
-func (ap *AiPlayer) negMax(b *Board, depth, alpha, beta int, passed bool, canUseFinal bool) int {
+// negMax searches the moves in the order of orderedMoves by principal variation search.
+// pv gets the principal variation when the score is between alpha and beta
+func (ap *AiPlayer) negMax(b *Board, depth, alpha, beta int, passed bool, canUseFinal bool, pv *[]Move) int {
 	var evaluate func() int
 
 	if canUseFinal {
@@ -130,42 +288,59 @@ func (ap *AiPlayer) negMax(b *Board, depth, alpha, beta int, passed bool, canUse
 
 	var score int
 
+	*pv = (*pv)[:0]
+
 	if depth == 0 {
 		// evaluate the current board
 		return evaluate()
 	}
 
+	if depth >= orderMinDepth && ap.cancelled() {
+		return 0
+	}
+
+	var line []Move
+
 	if !b.HasLegalMove(b.Turn) {
 		if passed {
 			// game finished
 			return evaluate()
 		} else {
-			b.SwitchTurn()
-			return -ap.negMax(b, depth, -beta, -alpha, true, canUseFinal)
+			// a copy, as the board can be searched again
+			passedBoard := b.CopyBoard()
+			passedBoard.SwitchTurn()
+
+			score = -ap.negMax(passedBoard, depth, -beta, -alpha, true, canUseFinal, &line)
+			*pv = append(append(*pv, Move{Colour: b.Turn, Pass: true}), line...)
+			return score
 		}
 	}
 
-	for cell := 0; cell < b.CellN; cell++ {
-		if !b.IsLegal(cell, b.Turn) {
-			continue
-		}
+	for i, m := range ap.orderedMoves(b, depth) {
+		placed := m.place(b)
 
-		// evaluate the current board
-		b, _ := b.Place(ap.cellToPosition(cell))
+		if i == 0 {
+			score = -ap.negMax(placed, depth-1, -beta, -alpha, false, canUseFinal, &line)
+		} else {
+			score = -ap.negMax(placed, depth-1, -alpha-1, -alpha, false, canUseFinal, &line)
+			if score > alpha && score < beta {
+				score = -ap.negMax(placed, depth-1, -beta, -alpha, false, canUseFinal, &line)
+			}
+		}
 
-		score = -ap.negMax(b, depth-1, -beta, -alpha, false, canUseFinal)
+		if score > max {
+			max = score
+			*pv = append(append((*pv)[:0], Move{Colour: b.Turn, Position: ap.cellToPosition(m.cell)}), line...)
+		}
 
 		if score >= beta {
+			ap.recordCutoff(b.Turn, m.cell, depth)
 			return score
 		}
 
 		if score > alpha {
 			alpha = score
 		}
-
-		if score > max {
-			max = score
-		}
 	}
 
 	return max
@@ -175,11 +350,16 @@ func (ap *AiPlayer) negMax(b *Board, depth, alpha, beta int, passed bool, canUse
 func (ap *AiPlayer) evaluateFinalBoard(b *Board) int {
 	score := 0
 
-	for i := 0; i < ap.N; i++ {
+	for i := 0; i < ap.H; i++ {
 		line := b.Lines[LineId(i)]
 		score += ap.EndScoreTable[i][line]
 	}
 
+	// fewer discs are better in anti-reversi
+	if b.Variant.Rule == RuleAnti {
+		score = -score
+	}
+
 	if !b.Turn == Black {
 		score = -score
 	}
@@ -194,9 +374,17 @@ func (ap *AiPlayer) evaluate(b *Board) int {
 	// - AI is black and depth is odd
 	score := 0
 
-	for i := 0; i < ap.N; i++ {
-		line := b.Lines[LineId(i)]
-		score += ap.ScoreTable[i][line]
+	if ap.patterns != nil {
+		score = ap.patterns.score(b)
+	} else {
+		for i := 0; i < ap.H; i++ {
+			line := b.Lines[LineId(i)]
+			score += ap.ScoreTable[i][line]
+		}
+	}
+
+	if b.Variant.Rule == RuleAnti {
+		score = -score
 	}
 
 	if !b.Turn == Black {
@@ -218,7 +406,7 @@ func (ap *AiPlayer) evaluate(b *Board) int {
 // }
 
 func (ap *AiPlayer) cellToPosition(cell int) Position {
-	return cellToPosition(ap.N, cell)
+	return cellToPosition(ap.W, cell)
 }
 
 var cellScore3 = [][]int{
@@ -272,6 +460,7 @@ var cellScore8 = [][]int{
 	{30, -12, 0, -1, -1, 0, -12, 30},
 }
 
+// place randomly
 func (ap *AiPlayer) getRandom(b *Board) Position {
 	availableCells := make([]int, 0, b.CellN)
 
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_ANALYSIS_DEPTH = 10
+	DEFAULT_ANALYSIS_TIME  = 5 * time.Second
+
+	// the analysis in the interactive UI
+	uiAnalysisDepth = 7
+	uiAnalysisTime  = time.Second
+	uiAnalysisMoves = 3
+
+	messageAnalyzing = "🔍  Analyzing..."
+	messageNoAnalyze = "There is no legal move to analyze."
+)
+
+// MoveAnalysis is the search result of a legal move
+type MoveAnalysis struct {
+	Position Position
+	Score    int    // from the view of the player to move
+	PV       []Move // principal variation, starting with the move
+	Depth    int
+}
+
+// Analyze searches every legal move with iterative deepening up to maxDepth,
+// and returns the results of the deepest finished iteration, best first.
+// The first iteration always finishes even after timeLimit. timeLimit 0 means no limit
+func (ap *AiPlayer) Analyze(b *Board, maxDepth int, timeLimit time.Duration) []MoveAnalysis {
+	ap.Colour = b.Turn
+	ap.evalCount = 0
+	ap.resetOrdering(maxDepth)
+
+	var deadline time.Time
+	if timeLimit > 0 {
+		deadline = time.Now().Add(timeLimit)
+	}
+
+	var results []MoveAnalysis
+
+	for depth := 1; depth <= maxDepth; depth++ {
+		iterationDeadline := deadline
+		if depth == 1 {
+			iterationDeadline = time.Time{}
+		}
+
+		iteration, err := ap.analyzeDepth(b, depth, iterationDeadline)
+		if err != nil {
+			break
+		}
+		results = iteration
+
+		// searched to the end of the game
+		if depth > b.CountEmptyCells() {
+			break
+		}
+	}
+
+	return results
+}
+
+// analyzeDepth searches each legal move with the full window, so that every move gets its exact score
+func (ap *AiPlayer) analyzeDepth(b *Board, depth int, deadline time.Time) ([]MoveAnalysis, error) {
+	defer ap.withDeadline(deadline)()
+
+	results := make([]MoveAnalysis, 0)
+
+	canUseFinal := b.CountEmptyCells() < depth
+
+	for _, m := range legalMoves(b) {
+		p := ap.cellToPosition(m.cell)
+
+		var pv []Move
+		score := -ap.negMax(m.place(b), depth-1, -9999, 9999, false, canUseFinal, &pv)
+		if err := ap.stopped(); err != nil {
+			return nil, err
+		}
+
+		results = append(results, MoveAnalysis{
+			Position: p,
+			Score:    score,
+			PV:       append([]Move{{Colour: b.Turn, Position: p}}, pv...),
+			Depth:    depth,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+
+	return results, nil
+}
+
+// search searches the board to the depth by negMax with the full window, and returns the score
+// for the player to move and the principal variation. It fails at the deadline or when ap.ctx is cancelled.
+// The zero deadline means no limit
+func (ap *AiPlayer) search(b *Board, depth int, canUseFinal bool, deadline time.Time) (int, []Move, error) {
+	defer ap.withDeadline(deadline)()
+
+	var pv []Move
+	score := ap.negMax(b, depth, -9999, 9999, false, canUseFinal, &pv)
+	if err := ap.stopped(); err != nil {
+		return 0, nil, err
+	}
+
+	return score, pv, nil
+}
+
+// withDeadline makes ap.ctx stop the search at the deadline too, and returns the function to put it back
+func (ap *AiPlayer) withDeadline(deadline time.Time) func() {
+	if deadline.IsZero() {
+		return func() {}
+	}
+
+	parent := ap.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithDeadline(parent, deadline)
+
+	saved := ap.ctx
+	ap.ctx = ctx
+
+	return func() {
+		cancel()
+		ap.ctx = saved
+	}
+}
+
+// stopped returns why the search stopped before it finished, nil if it finished
+func (ap *AiPlayer) stopped() error {
+	if ap.ctx == nil {
+		return nil
+	}
+	return ap.ctx.Err()
+}
+
+// formatPV returns the moves like "d3 c5 -- e6"
+func formatPV(pv []Move) string {
+	moves := make([]string, 0, len(pv))
+	for _, m := range pv {
+		moves = append(moves, formatMove(m))
+	}
+	return strings.Join(moves, " ")
+}
+
+// formatTopMoves returns the best moves in one line for the message
+func formatTopMoves(results []MoveAnalysis, n int) string {
+	if len(results) == 0 {
+		return messageNoAnalyze
+	}
+
+	moves := make([]string, 0, n)
+	for _, r := range results[:min(n, len(results))] {
+		moves = append(moves, fmt.Sprintf("%s %+d", r.Position, r.Score))
+	}
+
+	return fmt.Sprintf("💡  %s (depth %d)", strings.Join(moves, " | "), results[0].Depth)
+}
+
+// showAnalysis searches the board in the background and shows the best moves
+func showAnalysis(b *Board, d Renderer) {
+	d.Notify(messageAnalyzing)
+
+	b = b.CopyBoard()
+
+	go func() {
+		ap := newAiPlayerFor(b)
+		results := ap.Analyze(b, uiAnalysisDepth, uiAnalysisTime)
+		d.Notify(formatTopMoves(results, uiAnalysisMoves))
+	}()
+}
+
+// printAnalysis writes the board and the analysis of every legal move
+func printAnalysis(w io.Writer, b *Board, results []MoveAnalysis, elapsed time.Duration) {
+	for _, row := range boardRows(b) {
+		fmt.Fprintln(w, row)
+	}
+
+	totalB, totalW := b.Count()
+	fmt.Fprintf(w, "%s to move, Black %d, White %d\n\n", colourName(b.Turn), totalB, totalW)
+
+	if len(results) == 0 {
+		fmt.Fprintln(w, messageNoAnalyze)
+		return
+	}
+
+	fmt.Fprintf(w, "%-4s %6s %5s  %s\n", "Move", "Score", "Depth", "Principal variation")
+	for _, r := range results {
+		fmt.Fprintf(w, "%-4s %+6d %5d  %s\n", r.Position, r.Score, r.Depth, formatPV(r.PV))
+	}
+
+	fmt.Fprintf(w, "\nSearched to depth %d in %s\n", results[0].Depth, elapsed.Round(time.Millisecond))
+}
+
+func colourName(t Turn) string {
+	if t == Black {
+		return "Black"
+	}
+	return "White"
+}
+
+// parseColour parses "black" or "white"
+func parseColour(s string) (Turn, error) {
+	switch strings.ToLower(s) {
+	case "black", "b", "x":
+		return Black, nil
+	case "white", "w", "o":
+		return White, nil
+	}
+	return Black, fmt.Errorf("Unknown colour %q", s)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalyzeMatchesGetBest(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(6)
+	b, _ = b.Place(Position{3, 1})
+
+	ap := NewAiPlayer(6)
+	ap.depth = 4
+	want := ap.getPosition(b)
+
+	results := NewAiPlayer(6).Analyze(b, 4, 0)
+
+	assert.Equal(t, want, results[0].Position)
+	assert.Equal(t, 4, results[0].Depth)
+
+	legal := 0
+	for cell := 0; cell < b.CellN; cell++ {
+		if b.IsLegal(cell, b.Turn) {
+			legal++
+		}
+	}
+	assert.Equal(t, legal, len(results))
+
+	for i, r := range results {
+		if i > 0 {
+			assert.LessOrEqual(t, r.Score, results[i-1].Score)
+		}
+		assert.Equal(t, r.Position, r.PV[0].Position)
+	}
+}
+
+func TestAnalyzePV(t *testing.T) {
+	b, _ := parseBoardRows("---/-XO/-OX", White)
+
+	// searched to the end, so the score is the final disc difference
+	results := NewAiPlayer(3).Analyze(b, 20, 0)
+
+	for _, r := range results {
+		pvBoard := b
+		for _, m := range r.PV {
+			assert.Equal(t, pvBoard.Turn, m.Colour)
+
+			if m.Pass {
+				assert.False(t, pvBoard.HasLegalMove(pvBoard.Turn))
+				pvBoard = pvBoard.CopyBoard()
+				pvBoard.SwitchTurn()
+				continue
+			}
+
+			var err error
+			pvBoard, err = pvBoard.Place(m.Position)
+			assert.Nil(t, err)
+		}
+
+		totalB, totalW := pvBoard.Count()
+		assert.Equal(t, totalW-totalB, r.Score)
+	}
+}
+
+func TestAnalyzeTimeLimit(t *testing.T) {
+	start := time.Now()
+	results := NewAiPlayer(8).Analyze(NewBoard(8), 30, 50*time.Millisecond)
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, 4, len(results))
+	assert.GreaterOrEqual(t, results[0].Depth, 1)
+	assert.Less(t, results[0].Depth, 30)
+}
+
+func TestSearch(t *testing.T) {
+	b, _ := parseBoardRows("---/-XO/-OX", White)
+
+	// the same score as the analysis of the best move
+	score, pv, err := NewAiPlayer(3).search(b, 20, true, time.Time{})
+	assert.Nil(t, err)
+	assert.Equal(t, NewAiPlayer(3).Analyze(b, 20, 0)[0].Score, score)
+	assert.NotEmpty(t, pv)
+
+	// stops at the deadline
+	_, _, err = NewAiPlayer(8).search(NewBoard(8), 30, false, time.Now().Add(10*time.Millisecond))
+	assert.Error(t, err)
+}
+
+func TestFormatAnalysis(t *testing.T) {
+	results := []MoveAnalysis{
+		{Position{4, 2}, 5, []Move{{Colour: Black, Position: Position{4, 2}}, {Colour: White, Pass: true}}, 3},
+		{Position{2, 4}, -2, []Move{{Colour: Black, Position: Position{2, 4}}}, 3},
+	}
+
+	assert.Equal(t, "💡  e3 +5 | c5 -2 (depth 3)", formatTopMoves(results, 3))
+	assert.Equal(t, "💡  e3 +5 (depth 3)", formatTopMoves(results, 1))
+	assert.Equal(t, messageNoAnalyze, formatTopMoves(nil, 3))
+
+	out := &bytes.Buffer{}
+	printAnalysis(out, NewBoard(4), results, time.Second)
+
+	assert.Contains(t, out.String(), "Black to move, Black 2, White 2")
+	assert.Contains(t, out.String(), "e3       +5     3  e3 --\n")
+}
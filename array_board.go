@@ -7,43 +7,51 @@ package main
 
 import (
 	"fmt"
+	"strings"
 )
 
 type ArrayBoard struct {
-	N     int
+	W     int
+	H     int
 	Cells [][]State
 	Turn  Turn
 }
 
 func NewArrayBoard(n int) *ArrayBoard {
+	return NewRectArrayBoard(n, n)
+}
+
+func NewRectArrayBoard(w, h int) *ArrayBoard {
 	b := &ArrayBoard{}
-	b.init(n)
+	b.init(w, h)
 	return b
 }
 
-func (b *ArrayBoard) init(n int) {
-	b.N = n
+func (b *ArrayBoard) init(w, h int) {
+	b.W = w
+	b.H = h
 
 	b.Turn = Black
 
-	cells := make([][]State, n)
+	cells := make([][]State, h)
 
-	if n <= 2 {
+	if w <= 2 || h <= 2 {
 		panic("Board dimension needs to be more than 2. Even numbers are recommended")
 	}
 
-	middle := n/2 - 1
+	middleX := w/2 - 1
+	middleY := h/2 - 1
 
-	for y := 0; y < n; y++ {
-		cells[y] = make([]State, n)
-		for x := 0; x < n; x++ {
-			if y == middle && x == middle {
+	for y := 0; y < h; y++ {
+		cells[y] = make([]State, w)
+		for x := 0; x < w; x++ {
+			if y == middleY && x == middleX {
 				cells[y][x] = HasBlack
-			} else if y == middle+1 && x == middle+1 {
+			} else if y == middleY+1 && x == middleX+1 {
 				cells[y][x] = HasBlack
-			} else if y == middle && x == middle+1 {
+			} else if y == middleY && x == middleX+1 {
 				cells[y][x] = HasWhite
-			} else if y == middle+1 && x == middle {
+			} else if y == middleY+1 && x == middleX {
 				cells[y][x] = HasWhite
 			} else {
 				cells[y][x] = HasNothing
@@ -101,8 +109,8 @@ func (b *ArrayBoard) Place(p Position) error {
 }
 
 func (b *ArrayBoard) HasLegalCells() bool {
-	for x := 0; x < b.N; x++ {
-		for y := 0; y < b.N; y++ {
+	for x := 0; x < b.W; x++ {
+		for y := 0; y < b.H; y++ {
 			if b.Cells[y][x] != HasNothing {
 				continue
 			}
@@ -124,7 +132,7 @@ func (b *ArrayBoard) isCellTaken(p Position) bool {
 }
 
 func (b *ArrayBoard) GetCellsToFlip(x, y int) []CellToFlip {
-	cells := make([]CellToFlip, 0, b.N)
+	cells := make([]CellToFlip, 0, max(b.W, b.H))
 
 	var selfState State
 	var opponentState State
@@ -140,9 +148,9 @@ func (b *ArrayBoard) GetCellsToFlip(x, y int) []CellToFlip {
 	// check horizontally to right
 	tempCells := make([]CellToFlip, 0)
 
-	if x < b.N-2 && b.Cells[y][x+1] == opponentState {
+	if x < b.W-2 && b.Cells[y][x+1] == opponentState {
 	loop1:
-		for i := x + 1; i < b.N; i++ {
+		for i := x + 1; i < b.W; i++ {
 			switch b.Cells[y][i] {
 			case HasNothing:
 				break loop1
@@ -176,9 +184,9 @@ func (b *ArrayBoard) GetCellsToFlip(x, y int) []CellToFlip {
 	// check vertically to bottom
 	tempCells = make([]CellToFlip, 0)
 
-	if y < b.N-2 && b.Cells[y+1][x] == opponentState {
+	if y < b.H-2 && b.Cells[y+1][x] == opponentState {
 	loopVerBottom:
-		for i := y + 1; i < b.N; i++ {
+		for i := y + 1; i < b.H; i++ {
 			switch b.Cells[i][x] {
 			case HasNothing:
 				break loopVerBottom
@@ -212,9 +220,9 @@ func (b *ArrayBoard) GetCellsToFlip(x, y int) []CellToFlip {
 	// check diagonally to bottom right
 	tempCells = make([]CellToFlip, 0)
 
-	if x < b.N-2 && y < b.N-2 && b.Cells[y+1][x+1] == opponentState {
+	if x < b.W-2 && y < b.H-2 && b.Cells[y+1][x+1] == opponentState {
 	loopDiagBottomRight:
-		for i := 1; y+i < b.N && x+i < b.N; i++ {
+		for i := 1; y+i < b.H && x+i < b.W; i++ {
 			switch b.Cells[y+i][x+i] {
 			case HasNothing:
 				break loopDiagBottomRight
@@ -230,9 +238,9 @@ func (b *ArrayBoard) GetCellsToFlip(x, y int) []CellToFlip {
 	// check diagonally to bottom left
 	tempCells = make([]CellToFlip, 0)
 
-	if x >= 2 && y < b.N-2 && b.Cells[y+1][x-1] == opponentState {
+	if x >= 2 && y < b.H-2 && b.Cells[y+1][x-1] == opponentState {
 	loopDiagBottomLeft:
-		for i := 1; y+i < b.N && x-i >= 0; i++ {
+		for i := 1; y+i < b.H && x-i >= 0; i++ {
 			switch b.Cells[y+i][x-i] {
 			case HasNothing:
 				break loopDiagBottomLeft
@@ -248,9 +256,9 @@ func (b *ArrayBoard) GetCellsToFlip(x, y int) []CellToFlip {
 	// check diagonally to top right
 	tempCells = make([]CellToFlip, 0)
 
-	if x < b.N-2 && y >= 2 && b.Cells[y-1][x+1] == opponentState {
+	if x < b.W-2 && y >= 2 && b.Cells[y-1][x+1] == opponentState {
 	loopDiagTopRight:
-		for i := 1; y-i >= 0 && x+i < b.N; i++ {
+		for i := 1; y-i >= 0 && x+i < b.W; i++ {
 			switch b.Cells[y-i][x+i] {
 			case HasNothing:
 				break loopDiagTopRight
@@ -286,8 +294,8 @@ func (b *ArrayBoard) GetCellsToFlip(x, y int) []CellToFlip {
 func (b *ArrayBoard) Count() (int, int) {
 	var totalB, totalW int
 
-	for x := 0; x < b.N; x++ {
-		for y := 0; y < b.N; y++ {
+	for x := 0; x < b.W; x++ {
+		for y := 0; y < b.H; y++ {
 			if b.Cells[y][x] == HasWhite {
 				totalW++
 			} else if b.Cells[y][x] == HasBlack {
@@ -354,6 +362,32 @@ type Position struct {
 	Y int
 }
 
+// String returns the position in coordinate notation (e.g., Position{3, 2} is "d3")
+func (p Position) String() string {
+	return fmt.Sprintf("%c%d", 'a'+p.X, p.Y+1)
+}
+
+// parseCoordinate parses coordinate notation such as "d3"
+func parseCoordinate(s string) (Position, error) {
+	var col rune
+	var row int
+
+	s = strings.ToLower(s)
+
+	if _, err := fmt.Sscanf(s, "%c%d", &col, &row); err != nil || col < 'a' || col > 'z' || row < 1 {
+		return Position{}, fmt.Errorf("Invalid coordinate: %q", s)
+	}
+
+	p := Position{int(col - 'a'), row - 1}
+
+	// reject trailing characters
+	if p.String() != s {
+		return Position{}, fmt.Errorf("Invalid coordinate: %q", s)
+	}
+
+	return p, nil
+}
+
 func cellToPosition(n int, cell int) Position {
 	return Position{cell % n, cell / n}
 }
@@ -375,9 +409,9 @@ const (
 
 func (t Turn) String() string {
 	if t == Black {
-		return "○"
+		return BlackString
 	} else {
-		return "●"
+		return WhiteString
 	}
 }
 
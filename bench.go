@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_PERFT_DEPTH  = 6
+	DEFAULT_SEARCH_DEPTH = 7
+
+	// the positions for the search benchmark are after these numbers of moves
+	benchPositionPlies = 10
+	benchPositions     = 3
+)
+
+// benchOpenings are well-known openings for the move ordering benchmark.
+// The board starts with black on d4, so they are the standard moves upside down
+var benchOpenings = []struct {
+	name  string
+	moves string
+}{
+	{"Perpendicular", "f4 d3"},
+	{"Diagonal", "f4 f3"},
+	{"Parallel", "f4 f5"},
+	{"Tiger", "f4 d3 c6 d6 c5"},
+	{"Buffalo", "f4 f3 e3 f5 c6"},
+	{"Cow", "f4 d3 c4"},
+}
+
+// perftReference is the known leaf counts from the initial board. perftReference[n][d-1] is for depth d
+var perftReference = map[int][]int64{
+	8: {4, 12, 56, 244, 1396, 8200, 55092, 390216, 3005288, 24571284},
+}
+
+// perft counts the leaves of the legal move tree. A pass is counted as a move, and a finished game is a leaf
+func perft(b *Board, depth int) int64 {
+	if depth == 0 {
+		return 1
+	}
+
+	var nodes int64
+	moved := false
+
+	for cell := 0; cell < b.CellN; cell++ {
+		if !b.IsLegal(cell, b.Turn) {
+			continue
+		}
+
+		placed, _ := b.Place(cellToPosition(b.W, cell))
+		nodes += perft(placed, depth-1)
+		moved = true
+	}
+
+	if moved {
+		return nodes
+	}
+
+	// game finished
+	if !b.HasLegalMove(!b.Turn) {
+		return 1
+	}
+
+	passed := b.CopyBoard()
+	passed.SwitchTurn()
+
+	return perft(passed, depth-1)
+}
+
+// runPerft writes the leaf counts up to maxDepth with the speed, and returns an error if a count is different from the reference
+func runPerft(w io.Writer, n int, maxDepth int) error {
+	reference := perftReference[n]
+
+	fmt.Fprintf(w, "Perft %dx%d\n", n, n)
+	fmt.Fprintf(w, "%5s %12s %10s %12s  %s\n", "Depth", "Leaves", "Time", "Leaves/s", "Reference")
+
+	var mismatch error
+
+	b := NewBoard(n)
+
+	for depth := 1; depth <= maxDepth; depth++ {
+		start := time.Now()
+		nodes := perft(b, depth)
+		elapsed := time.Since(start)
+
+		check := "-"
+		if depth <= len(reference) {
+			if nodes == reference[depth-1] {
+				check = "ok"
+			} else {
+				check = fmt.Sprintf("NG (want %d)", reference[depth-1])
+				if mismatch == nil {
+					mismatch = fmt.Errorf("Perft of depth %d is %d, but it should be %d", depth, nodes, reference[depth-1])
+				}
+			}
+		}
+
+		fmt.Fprintf(w, "%5d %12d %10s %12.0f  %s\n", depth, nodes, elapsed.Round(time.Microsecond), perSecond(nodes, elapsed), check)
+	}
+
+	return mismatch
+}
+
+// benchBoards returns the positions for the search benchmark. They are made by the AI of depth 1, so they are the same every time
+func benchBoards(n int) []*Board {
+	boards := make([]*Board, 0, benchPositions)
+
+	b := NewBoard(n)
+	ap := NewAiPlayerWithLevel(n, 2)
+
+	for plies := 0; len(boards) < benchPositions; plies++ {
+		if plies%benchPositionPlies == 0 {
+			boards = append(boards, b)
+		}
+
+		if !b.HasLegalMove(b.Turn) {
+			if !b.HasLegalMove(!b.Turn) {
+				break
+			}
+			b = b.CopyBoard()
+			b.SwitchTurn()
+			continue
+		}
+
+		b, _ = b.Place(ap.getPosition(b))
+	}
+
+	return boards
+}
+
+// runSearchBench searches the benchmark positions and writes the evaluated nodes per second
+func runSearchBench(w io.Writer, n int, depth int, threads int) {
+	fmt.Fprintf(w, "Search %dx%d, depth %d, %d threads\n", n, n, depth, threads)
+	fmt.Fprintf(w, "%8s %12s %10s %12s  %s\n", "Position", "Nodes", "Time", "Nodes/s", "Principal variation")
+
+	var totalNodes int64
+	var totalTime time.Duration
+
+	for i, b := range benchBoards(n) {
+		ap := NewAiPlayer(n)
+		ap.depth = depth
+		ap.Threads = threads
+
+		start := time.Now()
+		_, pv := ap.getBest(b)
+		elapsed := time.Since(start)
+
+		nodes := int64(ap.evalCount)
+		totalNodes += nodes
+		totalTime += elapsed
+
+		fmt.Fprintf(w, "%8d %12d %10s %12.0f  %s\n", i+1, nodes, elapsed.Round(time.Microsecond), perSecond(nodes, elapsed), formatPV(pv))
+	}
+
+	fmt.Fprintf(w, "%8s %12d %10s %12.0f\n", "Total", totalNodes, totalTime.Round(time.Microsecond), perSecond(totalNodes, totalTime))
+}
+
+// openingBoards returns the positions after the benchOpenings
+func openingBoards() ([]*Board, error) {
+	boards := make([]*Board, 0, len(benchOpenings))
+
+	for _, o := range benchOpenings {
+		positions, _, err := Record{N: 8, Moves: strings.Fields(o.moves)}.Positions()
+		if err != nil {
+			return nil, fmt.Errorf("Opening %s: %w", o.name, err)
+		}
+		boards = append(boards, positions[len(positions)-1])
+	}
+
+	return boards, nil
+}
+
+// runOrderingBench searches the 8x8 openings with and without the move ordering, and writes the evaluated nodes of each
+func runOrderingBench(w io.Writer, depth int) error {
+	boards, err := openingBoards()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Move ordering 8x8, depth %d\n", depth)
+	fmt.Fprintf(w, "%-14s %12s %12s %10s\n", "Opening", "Cell order", "Ordered", "Reduction")
+
+	var totalPlain, totalOrdered int64
+
+	for i, b := range boards {
+		ap := NewAiPlayer(8)
+		ap.depth = depth
+
+		ap.plainOrder = true
+		ap.getBest(b)
+		plain := int64(ap.evalCount)
+
+		ap.plainOrder = false
+		ap.getBest(b)
+		ordered := int64(ap.evalCount)
+
+		totalPlain += plain
+		totalOrdered += ordered
+
+		fmt.Fprintf(w, "%-14s %12d %12d %9.1f%%\n", benchOpenings[i].name, plain, ordered, reduction(plain, ordered))
+	}
+
+	fmt.Fprintf(w, "%-14s %12d %12d %9.1f%%\n", "Total", totalPlain, totalOrdered, reduction(totalPlain, totalOrdered))
+
+	return nil
+}
+
+// reduction returns how many percent fewer nodes the ordered search evaluated
+func reduction(plain, ordered int64) float64 {
+	if plain == 0 {
+		return 0
+	}
+	return 100 * float64(plain-ordered) / float64(plain)
+}
+
+func perSecond(count int64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(count) / elapsed.Seconds()
+}
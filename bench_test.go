@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerft(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	b := NewBoard(8)
+
+	for depth := 1; depth <= DEFAULT_PERFT_DEPTH; depth++ {
+		assert.Equal(t, perftReference[8][depth-1], perft(b, depth), "depth %d", depth)
+	}
+}
+
+// arrayPerft is perft on the old array board, to check the indexed board with another implementation
+func arrayPerft(b *ArrayBoard, depth int) int64 {
+	if depth == 0 {
+		return 1
+	}
+
+	var nodes int64
+	moved := false
+
+	for y := 0; y < b.H; y++ {
+		for x := 0; x < b.W; x++ {
+			if b.Cells[y][x] != HasNothing || len(b.GetCellsToFlip(x, y)) == 0 {
+				continue
+			}
+
+			placed := copyArrayBoard(b)
+			placed.Place(Position{x, y})
+			nodes += arrayPerft(placed, depth-1)
+			moved = true
+		}
+	}
+
+	if moved {
+		return nodes
+	}
+
+	passed := copyArrayBoard(b)
+	passed.SwitchTurn()
+	if !passed.HasLegalCells() {
+		return 1
+	}
+
+	return arrayPerft(passed, depth-1)
+}
+
+func copyArrayBoard(b *ArrayBoard) *ArrayBoard {
+	cells := make([][]State, b.H)
+	for y := range b.Cells {
+		cells[y] = append([]State{}, b.Cells[y]...)
+	}
+
+	return &ArrayBoard{W: b.W, H: b.H, Cells: cells, Turn: b.Turn}
+}
+
+func TestPerftMatchesArrayBoard(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	for n := MIN_N; n <= 8; n++ {
+		depth := 7
+		if n >= 6 {
+			depth = 5
+		}
+
+		for d := 1; d <= depth; d++ {
+			assert.Equal(t, arrayPerft(NewArrayBoard(n), d), perft(NewBoard(n), d), "%dx%d depth %d", n, n, d)
+		}
+	}
+}
+
+func TestPerftMatchesArrayBoardOnRectangles(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	sizes := []struct {
+		W, H, Depth int
+	}{
+		{4, 3, 8},
+		{3, 5, 8},
+		{6, 4, 6},
+		{5, 7, 5},
+		{4, 8, 5},
+		{10, 6, 4},
+		{6, 10, 4},
+	}
+
+	for _, s := range sizes {
+		for d := 1; d <= s.Depth; d++ {
+			assert.Equal(t, arrayPerft(NewRectArrayBoard(s.W, s.H), d), perft(NewRectBoard(s.W, s.H, Variant{}), d), "%dx%d depth %d", s.W, s.H, d)
+		}
+	}
+}
+
+func TestRunPerft(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	var out bytes.Buffer
+
+	err := runPerft(&out, 8, 4)
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Perft 8x8")
+	assert.Regexp(t, `(?m)^\s+4\s+244\s.*ok$`, out.String())
+}
+
+func TestRunPerftMismatch(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	reference := perftReference[8]
+	defer func() { perftReference[8] = reference }()
+
+	perftReference[8] = []int64{4, 13}
+	var out bytes.Buffer
+
+	err := runPerft(&out, 8, 3)
+
+	assert.EqualError(t, err, "Perft of depth 2 is 12, but it should be 13")
+	assert.Contains(t, out.String(), "NG (want 13)")
+	assert.Regexp(t, `(?m)^\s+3\s+56\s.*-$`, out.String())
+}
+
+func TestBenchBoards(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	boards := benchBoards(8)
+
+	assert.Len(t, boards, benchPositions)
+	assert.Equal(t, 60, boards[0].CountEmptyCells())
+	assert.Equal(t, 50, boards[1].CountEmptyCells())
+	assert.Equal(t, benchBoards(8)[2].String(), boards[2].String())
+}
+
+func TestRunSearchBench(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	var out bytes.Buffer
+
+	runSearchBench(&out, 6, 3, 2)
+
+	assert.Contains(t, out.String(), "Search 6x6, depth 3, 2 threads")
+	assert.Regexp(t, `(?m)^\s+Total\s+[1-9]\d*\s`, out.String())
+}
+
+func BenchmarkPerft(b *testing.B) {
+	logger = NewLogger(slog.LevelInfo)
+	board := NewBoard(8)
+
+	for i := 0; i < b.N; i++ {
+		perft(board, 6)
+	}
+}
+
+func BenchmarkPlace(b *testing.B) {
+	logger = NewLogger(slog.LevelInfo)
+	board := NewBoard(8)
+	p := Position{4, 2}
+
+	for i := 0; i < b.N; i++ {
+		board.Place(p)
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	logger = NewLogger(slog.LevelInfo)
+	board := benchBoards(8)[1]
+
+	for i := 0; i < b.N; i++ {
+		ap := NewAiPlayer(8)
+		ap.depth = 5
+		ap.getPosition(board)
+	}
+}
+
+func TestOpeningBoards(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	boards, err := openingBoards()
+
+	assert.NoError(t, err)
+	assert.Len(t, boards, len(benchOpenings))
+	assert.Equal(t, 58, boards[0].CountEmptyCells())
+}
+
+func TestRunOrderingBench(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	var out bytes.Buffer
+
+	err := runOrderingBench(&out, 3)
+
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Move ordering 8x8, depth 3")
+	assert.Regexp(t, `(?m)^Tiger\s+[1-9]\d*\s+[1-9]\d*\s`, out.String())
+	assert.Regexp(t, `(?m)^Total\s+[1-9]\d*\s+[1-9]\d*\s+-?\d+\.\d%$`, out.String())
+}
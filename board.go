@@ -1,15 +1,25 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
+	"slices"
 	"strings"
+	"sync"
 )
 
 // mobility[index][0:black/1:white][cell position in row]
 // = [backward flip cells num, forward flip cells num]
 type Mobility map[Idx]map[Turn][][]int
 
+// the mobility of each line length, shared by the boards as it's never changed.
+// It takes a while to make for long lines, e.g., 10 cells of a 10x6 board
+var (
+	mobilities   = make(map[int]Mobility)
+	mobilitiesMu sync.Mutex
+)
+
 type Lines map[LineId]Idx
 type LineId int
 
@@ -31,7 +41,9 @@ type LineForCell struct {
 }
 
 type Board struct {
-	N     int // dimension of the board
+	N     int // dimension of the board, the longer side of a rectangle. Every line is an idx of N cells
+	W     int // width, the number of cells in a row
+	H     int // height, the number of rows
 	CellN int // number of cells (e.g., if N=8, CellN=64)
 	IdxN  int // number of possible pattern for one index (if N=8, it's 3^8)
 	// number of indexes
@@ -46,10 +58,35 @@ type Board struct {
 	mobility Mobility
 
 	Turn Turn
+
+	Variant   Variant
+	setupLeft int // discs still to be placed on the centre in the free opening
+
+	// cells no one can place on, nil if there is none. They are empty in the lines,
+	// so they break the flipping lines like empty cells. Shared by the copies
+	holes []bool
+	holeN int
 }
 
 func NewBoard(n int) *Board {
-	b := &Board{N: n, Turn: Black}
+	return NewBoardWithVariant(n, Variant{})
+}
+
+// NewBoardWithVariant returns the initial board of the variant. Empty fields of the variant are the standard rules
+func NewBoardWithVariant(n int, v Variant) *Board {
+	return NewRectBoard(n, n, v)
+}
+
+// NewRectBoard returns the initial board of w x h cells, e.g., 10x6
+func NewRectBoard(w, h int, v Variant) *Board {
+	if v.Rule == "" {
+		v.Rule = RuleStandard
+	}
+	if v.Opening == "" {
+		v.Opening = OpeningStandard
+	}
+
+	b := &Board{N: max(w, h), W: w, H: h, Turn: Black, Variant: v}
 	b.init()
 	return b
 }
@@ -57,12 +94,18 @@ func NewBoard(n int) *Board {
 func (b *Board) CopyBoard() *Board {
 	copied := &Board{
 		N:            b.N,
+		W:            b.W,
+		H:            b.H,
 		CellN:        b.CellN,
 		IdxN:         b.IdxN,
 		LineN:        b.LineN,
 		LineForCells: b.LineForCells,
 		mobility:     b.mobility,
 		Turn:         b.Turn,
+		Variant:      b.Variant,
+		setupLeft:    b.setupLeft,
+		holes:        b.holes,
+		holeN:        b.holeN,
 	}
 
 	lines := make(Lines)
@@ -76,18 +119,58 @@ func (b *Board) CopyBoard() *Board {
 	return copied
 }
 
+// boardJSON is the board sent over the connection. The unexported fields are not encoded,
+// so the setup is sent with it, and the mobility and the holes are made again when it's decoded
+type boardJSON struct {
+	boardFields
+	SetupLeft int `json:",omitempty"`
+}
+
+// boardFields has the fields of Board without its methods, so that it's encoded in the default way
+type boardFields Board
+
+func (b *Board) MarshalJSON() ([]byte, error) {
+	return json.Marshal(boardJSON{boardFields(*b), b.setupLeft})
+}
+
+func (b *Board) UnmarshalJSON(data []byte) error {
+	var decoded boardJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	if decoded.W < MIN_N || decoded.W > MAX_N || decoded.H < MIN_N || decoded.H > MAX_N || decoded.N != max(decoded.W, decoded.H) {
+		return fmt.Errorf("Invalid board size %dx%d", decoded.W, decoded.H)
+	}
+
+	if err := validateHoles(decoded.W, decoded.H, decoded.Variant.Holes); err != nil {
+		return fmt.Errorf("Invalid board: %w", err)
+	}
+
+	*b = Board(decoded.boardFields)
+	b.setupLeft = decoded.SetupLeft
+	b.mobility = sharedMobility(b.N)
+	b.holes = holeMask(b.W, b.H, b.Variant.Holes)
+	b.holeN = len(b.Variant.Holes)
+
+	return nil
+}
+
 func (b *Board) init() {
 	b.Turn = Black
 
-	b.CellN = b.N * b.N
+	b.CellN = b.W * b.H
 
-	b.LineN = b.calcLineN(b.N)
+	b.LineN = calcRectLineN(b.W, b.H)
 
 	b.IdxN = pow(3, b.N)
 
-	b.LineForCells = NewLineForCells(b.N)
+	b.LineForCells = NewRectLineForCells(b.W, b.H)
+
+	b.mobility = sharedMobility(b.N)
 
-	b.mobility = NewMobility(b.N)
+	b.holes = holeMask(b.W, b.H, b.Variant.Holes)
+	b.holeN = len(b.Variant.Holes)
 
 	b.initLines()
 }
@@ -97,6 +180,11 @@ func (b *Board) Replay() {
 
 	b.IdxN = pow(3, b.N)
 
+	// a new random start for the next game
+	if b.Variant.Opening == OpeningRandom {
+		b.Variant.Start = ""
+	}
+
 	b.initLines()
 }
 
@@ -107,97 +195,136 @@ func (b *Board) initLines() {
 		b.Lines[LineId(i)] = Idx{0, b.N}
 	}
 
-	middle1 := b.N/2 - 1
-	middle2 := b.N / 2
-	initB1 := b.N*(middle1) + middle1
-	initB2 := b.N*(middle2) + middle2
-	initW1 := b.N*(middle1) + middle2
-	initW2 := b.N*(middle2) + middle1
+	b.setupLeft = 0
+
+	if b.Variant.Position != "" {
+		b.initPosition()
+		return
+	}
+
+	if b.Variant.Opening == OpeningFree {
+		b.setupLeft = len(centreCells(b.W, b.H))
+		return
+	}
+
+	if b.Variant.Start == "" {
+		b.Variant.Start = openingStart(b.Variant.Opening)
+	}
+
+	// the start is the centre cells row by row, e.g., "bwwb" for the standard cross
+	for i, cell := range centreCells(b.W, b.H) {
+		if b.Variant.Start[i] == 'b' {
+			b.updateCellState(cell, Black)
+		} else {
+			b.updateCellState(cell, White)
+		}
+	}
+}
+
+// centreCells returns the 4 cells in the middle of the board of w x h, row by row
+func centreCells(w, h int) []int {
+	x1, x2 := w/2-1, w/2
+	y1, y2 := h/2-1, h/2
+
+	return []int{
+		w*y1 + x1,
+		w*y1 + x2,
+		w*y2 + x1,
+		w*y2 + x2,
+	}
+}
+
+// IsHole tells that no one can place on the cell
+func (b *Board) IsHole(p Position) bool {
+	return b.holes != nil && b.holes[p.X+p.Y*b.W]
+}
 
-	b.updateCellState(initB1, Black)
-	b.updateCellState(initB2, Black)
-	b.updateCellState(initW1, White)
-	b.updateCellState(initW2, White)
+// InSetup tells that the players are placing the first discs of the free opening, which flip nothing
+func (b *Board) InSetup() bool {
+	return b.setupLeft > 0
 }
 
 func (b *Board) calcLineN(n int) int {
-	// 2*b.N = N rows + N cols
-	// There are 2*(2*b.N-1) diagnol lines for 2 directions (backslash \ and slash / directions)
-	// 8 of them only have 1 or 2 cells, so it can't have any legal cell
-	return 2*n + 2*(2*n-1) - 8
+	return calcRectLineN(n, n)
+}
+
+// calcRectLineN returns the number of lines of a board of w x h
+func calcRectLineN(w, h int) int {
+	// w+h = h rows + w cols
+	// There are w+h-1 diagnol lines for each of 2 directions (backslash \ and slash / directions)
+	// 4 of them only have 1 or 2 cells, so it can't have any legal cell
+	return w + h + 2*(w+h-1-4)
 }
 
 func NewLineForCells(n int) LineForCells {
-	cellN := n * n
+	return NewRectLineForCells(n, n)
+}
+
+// NewRectLineForCells returns the lines of each cell of a board of w x h.
+// The ids are rows, columns, backslash \ diagonals, then slash / diagonals.
+// Diagonals of 1 or 2 cells are skipped as they can't flip anything
+func NewRectLineForCells(w, h int) LineForCells {
+	cellN := w * h
 
 	lineForCells := make([][]LineForCell, cellN)
 
 	// add row indexes
 	for cell := 0; cell < cellN; cell++ {
-		rowLine := cell / n
-		colLine := cell%n + n
+		rowLine := cell / w
+		colLine := cell%w + h
 
 		// colIdx is local position in row idx and vice versa
-		rowLineForCell := LineForCell{LineId(rowLine), colLine - n, Row}
+		rowLineForCell := LineForCell{LineId(rowLine), colLine - h, Row}
 		colLineForCell := LineForCell{LineId(colLine), rowLine, Col}
 		lineForCells[cell] = []LineForCell{rowLineForCell, colLineForCell}
 	}
 
-	// add backslash \ diagnal lines
-	for i := 0; i < n*2-5; i++ {
-		local := 0
-		middle := (n*2 - 5) / 2
+	line := w + h
 
-		// left top lines starts from n*2
-		line := n*2 + i
-
-		var startCell int
-		if i < middle {
-			// start with 3rd lowest row, first column, going upper row
-			startCell = n * (n - 3 - i)
-		} else {
-			// start with 1st cell, moving left
-			startCell = i - middle
+	// addDiagonal adds the line from (x, y) going down by (dx, 1) to the end of the board
+	addDiagonal := func(x, y, dx int, lineType LineType) {
+		for local := 0; x >= 0 && x < w && y < h; local++ {
+			cell := x + y*w
+			lineForCells[cell] = append(lineForCells[cell], LineForCell{LineId(line), local, lineType})
+			x, y = x+dx, y+1
 		}
+		line++
+	}
 
-		for cell := startCell; cell < cellN; cell += n + 1 {
-			lineForCells[cell] = append(lineForCells[cell], LineForCell{LineId(line), local, BackSlash})
-			local++
-			// finish if cell reaches the right end column
-			if cell != startCell && cell%n == n-1 {
-				break
-			}
-		}
+	// add backslash \ diagnal lines
+	// start with 3rd lowest row, first column, going upper row
+	for y := h - 3; y >= 1; y-- {
+		addDiagonal(0, y, 1, BackSlash)
+	}
+	// start with 1st cell, moving right
+	for x := 0; x <= w-3; x++ {
+		addDiagonal(x, 0, 1, BackSlash)
 	}
 
 	// add slash / diagnal lines
-	for i := 0; i < n*2-5; i++ {
-		local := 0
-		middle := (n*2 - 5) / 2
+	// start with 1st row, 3rd cell, moving 1 col
+	for x := 2; x <= w-2; x++ {
+		addDiagonal(x, 0, -1, Slash)
+	}
+	// start with the end cell of first row, moving to the next row
+	for y := 0; y <= h-3; y++ {
+		addDiagonal(w-1, y, -1, Slash)
+	}
 
-		// right top line starts from n*2 + n*2 - 5
-		line := n*2 + n*2 - 5 + i
+	return lineForCells
+}
 
-		var startCell int
-		if i < middle {
-			// start with 1st row, 2nd cell, moving 1 col
-			startCell = i + 2
-		} else {
-			// start with the end cell of first row, moving to the next row ading n
-			startCell = (n - 1) + n*(i-middle)
-		}
+func sharedMobility(n int) Mobility {
+	mobilitiesMu.Lock()
+	defer mobilitiesMu.Unlock()
 
-		for cell := startCell; cell < cellN; cell += n - 1 {
-			lineForCells[cell] = append(lineForCells[cell], LineForCell{LineId(line), local, Slash})
-			local++
-			// finish if cell reaches left end colum
-			if cell != startCell && cell%n == 0 {
-				break
-			}
-		}
+	if m, ok := mobilities[n]; ok {
+		return m
 	}
 
-	return lineForCells
+	m := NewMobility(n)
+	mobilities[n] = m
+	return m
 }
 
 func NewMobility(n int) Mobility {
@@ -312,6 +439,14 @@ func getFlippingCells(idx Idx, local, n int, selfState, opponentState State) (in
 }
 
 func (b *Board) IsLegal(cell int, t Turn) bool {
+	if b.holes != nil && b.holes[cell] {
+		return false
+	}
+
+	if b.setupLeft > 0 {
+		return slices.Contains(centreCells(b.W, b.H), cell) && b.GetCellState(cellToPosition(b.W, cell)) == HasNothing
+	}
+
 	idxForCells := b.LineForCells[cell]
 
 	flippingCellsNum := 0
@@ -333,6 +468,17 @@ func (b *Board) HasLegalMove(t Turn) bool {
 	return hasLegal
 }
 
+// CountLegalMoves returns the number of cells the player can place on
+func (b *Board) CountLegalMoves(t Turn) int {
+	count := 0
+	for i := 0; i < b.CellN; i++ {
+		if b.IsLegal(i, t) {
+			count++
+		}
+	}
+	return count
+}
+
 func (b *Board) GetCellState(p Position) State {
 	idx := b.Lines[LineId(p.Y)]
 
@@ -340,7 +486,7 @@ func (b *Board) GetCellState(p Position) State {
 }
 
 func (b *Board) Place(p Position) (*Board, error) {
-	cell := p.X + p.Y*b.N
+	cell := p.X + p.Y*b.W
 
 	t := b.Turn
 
@@ -359,6 +505,12 @@ func (b *Board) Place(p Position) (*Board, error) {
 
 // PlaceWithoutCheck only place the disk, without the legality or switching turn
 func (b *Board) PlaceWithoutCheck(cell int, t Turn) {
+	if b.setupLeft > 0 {
+		b.updateCellState(cell, t)
+		b.setupLeft--
+		return
+	}
+
 	lineForCells := b.LineForCells[cell]
 
 	idxs := make([]Idx, 0, 4)
@@ -377,11 +529,11 @@ func (b *Board) PlaceWithoutCheck(cell int, t Turn) {
 		case Row:
 			gap = 1
 		case Col:
-			gap = b.N
+			gap = b.W
 		case BackSlash:
-			gap = b.N + 1
+			gap = b.W + 1
 		case Slash:
-			gap = b.N - 1
+			gap = b.W - 1
 		}
 
 		idx := idxs[l]
@@ -414,9 +566,9 @@ func (b *Board) SwitchTurn() {
 func (b *Board) Count() (int, int) {
 	var totalB, totalW int
 
-	for i := 0; i < b.N; i++ {
+	for i := 0; i < b.H; i++ {
 		idx := b.Lines[LineId(i)]
-		for local := 0; local < b.N; local++ {
+		for local := 0; local < b.W; local++ {
 			state := idx.GetLocalState(local)
 
 			switch state {
@@ -431,12 +583,22 @@ func (b *Board) Count() (int, int) {
 	return totalB, totalW
 }
 
+// Result returns the disc difference for black, which is negative in anti-reversi so that it's positive when black wins
+func (b *Board) Result() int {
+	totalB, totalW := b.Count()
+
+	if b.Variant.Rule == RuleAnti {
+		return totalW - totalB
+	}
+	return totalB - totalW
+}
+
 func (b *Board) CountEmptyCells() int {
 	var total int
 
-	for i := 0; i < b.N; i++ {
+	for i := 0; i < b.H; i++ {
 		idx := b.Lines[LineId(i)]
-		for local := 0; local < b.N; local++ {
+		for local := 0; local < b.W; local++ {
 			state := idx.GetLocalState(local)
 			if state == HasNothing {
 				total++
@@ -444,10 +606,13 @@ func (b *Board) CountEmptyCells() int {
 		}
 	}
 
-	return total
+	// holes are never filled
+	return total - b.holeN
 }
 
 func (b *Board) FromStringCells(cellsStr [][]string) {
+	b.setupLeft = 0
+
 	// reset lines
 	for lineId := range b.Lines {
 		b.Lines[lineId] = Idx{0, b.N}
@@ -456,7 +621,7 @@ func (b *Board) FromStringCells(cellsStr [][]string) {
 	// place according to the string
 	for y, row := range cellsStr {
 		for x, char := range row {
-			cell := y*b.N + x
+			cell := y*b.W + x
 
 			for _, lineForCell := range b.LineForCells[cell] {
 				lineId, local := lineForCell.LineId, lineForCell.Local
@@ -480,7 +645,7 @@ func (b *Board) String() string {
 	var builder strings.Builder
 
 	fmt.Fprintln(&builder, "")
-	for i := 0; i < b.N; i++ {
+	for i := 0; i < b.H; i++ {
 		idx := b.Lines[LineId(i)]
 
 		fmt.Fprintln(&builder, idx.String())
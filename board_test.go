@@ -1,7 +1,9 @@
 package main
 
 import (
+	"encoding/json"
 	"log/slog"
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -174,3 +176,120 @@ func TestIndexedBoardCount(t *testing.T) {
 	assert.Equal(t, 5, totalB)
 	assert.Equal(t, 1, totalW)
 }
+
+func TestRectBoardLines(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	// 3 rows, 4 columns and 2 diagonals of each direction
+	assert.Equal(t, 11, calcRectLineN(4, 3))
+	assert.Equal(t, 38, calcRectLineN(8, 8))
+
+	idxForCells := NewRectLineForCells(4, 3)
+
+	// b2
+	assert.Equal(t, LineForCell{1, 1, Row}, idxForCells[5][0])
+	assert.Equal(t, LineForCell{4, 1, Col}, idxForCells[5][1])
+	assert.Equal(t, LineForCell{7, 1, BackSlash}, idxForCells[5][2])
+	assert.Equal(t, LineForCell{9, 1, Slash}, idxForCells[5][3])
+
+	// d3 is only on a row, a column and a backslash
+	assert.Len(t, idxForCells[11], 3)
+	assert.Equal(t, LineForCell{8, 2, BackSlash}, idxForCells[11][2])
+
+	b := NewRectBoard(10, 6, Variant{})
+	assert.Equal(t, 10, b.N)
+	assert.Equal(t, 60, b.CellN)
+	assert.Equal(t, []string{"----------", "----------", "----XO----", "----OX----", "----------", "----------"}, boardRows(b))
+}
+
+// TestRectBoardMatchesArrayBoard plays random games on both boards and compares every position
+func TestRectBoardMatchesArrayBoard(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	rng := rand.New(rand.NewSource(1))
+
+	for _, size := range [][2]int{{4, 3}, {6, 4}, {5, 8}, {10, 6}, {7, 10}} {
+		w, h := size[0], size[1]
+
+		for game := 0; game < 10; game++ {
+			b := NewRectBoard(w, h, Variant{})
+			ab := NewRectArrayBoard(w, h)
+
+			for b.HasLegalMove(Black) || b.HasLegalMove(White) {
+				if !b.HasLegalMove(b.Turn) {
+					b.SwitchTurn()
+					ab.SwitchTurn()
+				}
+
+				legal := legalPositions(b)
+
+				arrayLegal := make([]Position, 0)
+				for y := 0; y < h; y++ {
+					for x := 0; x < w; x++ {
+						if ab.Cells[y][x] == HasNothing && len(ab.GetCellsToFlip(x, y)) > 0 {
+							arrayLegal = append(arrayLegal, Position{x, y})
+						}
+					}
+				}
+				assert.Equal(t, arrayLegal, legal, "%dx%d", w, h)
+
+				p := legal[rng.Intn(len(legal))]
+				b, _ = b.Place(p)
+				ab.Place(p)
+
+				for y, row := range boardRows(b) {
+					for x, c := range row {
+						assert.Equal(t, ab.Cells[y][x].String(), pipeToState(string(c)), "%dx%d %s", w, h, p)
+					}
+				}
+			}
+		}
+	}
+}
+
+// pipeToState converts a cell of the board rows to the string of the array board
+func pipeToState(c string) string {
+	switch c {
+	case PipeBlack:
+		return HasBlack.String()
+	case PipeWhite:
+		return HasWhite.String()
+	}
+	return HasNothing.String()
+}
+
+func TestBoardJSONRoundTrip(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	// the guest receives the game of the host in JSON
+	g := NewGame(NewBoardWithVariant(6, Variant{Holes: []string{"e3", "b4"}}), Human, Human)
+	g.Board, _ = g.Board.Place(Position{2, 1})
+
+	data, err := json.Marshal(g)
+	assert.NoError(t, err)
+
+	var decoded Game
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+
+	b := decoded.Board
+	assert.Equal(t, g.Board.String(), b.String())
+	assert.True(t, b.IsHole(Position{4, 2}))
+	assert.True(t, b.IsHole(Position{1, 3}))
+	assert.Equal(t, g.Board.CountLegalMoves(White), b.CountLegalMoves(White))
+
+	// the analysis key searches the decoded board
+	assert.NotEmpty(t, newAiPlayerFor(b).Analyze(b, 2, 0))
+
+	free := NewBoardWithVariant(8, Variant{Opening: OpeningFree})
+
+	data, err = json.Marshal(free)
+	assert.NoError(t, err)
+
+	decodedFree := &Board{}
+	assert.NoError(t, json.Unmarshal(data, decodedFree))
+	assert.True(t, decodedFree.InSetup())
+	assert.True(t, decodedFree.IsLegal(27, Black))
+	assert.False(t, decodedFree.IsLegal(0, Black))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"N": 20, "W": 20, "H": 20}`), &Board{}))
+}
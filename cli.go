@@ -0,0 +1,791 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	// the sides of the board, square or rectangular
+	MIN_N = 3
+	MAX_N = 10
+)
+
+// Command is a subcommand of the CLI, e.g., "reversi play"
+type Command struct {
+	Name    string
+	Args    string // arguments after the options in the usage, e.g., "URL"
+	Summary string
+	// Flags defines the options and returns the function to run the command with the arguments
+	Flags func(fs *flag.FlagSet) func(args []string) error
+}
+
+// usageError is an error caused by wrong options or arguments
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usageErrorf(format string, a ...any) error {
+	return &usageError{fmt.Sprintf(format, a...)}
+}
+
+func commands(cfg *Config) []*Command {
+	return []*Command{
+		{
+			Name:    "play",
+			Summary: "Play on this terminal against the AI or another local player",
+			Flags: func(fs *flag.FlagSet) func(args []string) error {
+				board := addBoardFlags(fs, cfg)
+				playerNum := fs.Int("p", 1, "1 for Single Play, 2 for 2 Players")
+				level := fs.Int("level", cfg.AiLevel, "Strength of the AI, from 1 (random) to 5")
+				engine := fs.String("engine", ENGINE_ALPHABETA, "Search of the AI, alphabeta or mcts (Monte Carlo tree search)")
+				threads := fs.Int("threads", runtime.NumCPU(), "Number of moves the alphabeta AI searches at the same time")
+				ponder := fs.Bool("ponder", false, "Let the AI think during your turn, on the same number of threads")
+				evalBar := fs.Bool("evalbar", false, "Show the chance of winning beside the board, estimated by the AI after every move")
+				name := fs.String("name", cfg.PlayerName, "Your name shown in the game")
+				record := fs.String("record", "", "Save the record of the game to the file when the game ends")
+				pipe := fs.Bool("pipe", false, "Read commands from stdin and write the board to stdout")
+				isDebugging := fs.Bool("d", false, "Debug info")
+
+				return func(args []string) error {
+					if err := noArgs(args); err != nil {
+						return err
+					}
+					w, h, v, err := board.parse()
+					if err != nil {
+						return err
+					}
+					if *playerNum != 1 && *playerNum != 2 {
+						return usageErrorf("-p must be 1 or 2")
+					}
+					if err := validateAiLevel(*level); err != nil {
+						return err
+					}
+
+					if *threads < 1 {
+						return usageErrorf("-threads must be 1 or more")
+					}
+
+					ai, err := newEngine(*engine, w, h, *level, *threads)
+					if err != nil {
+						return usageErrorf("%s", err)
+					}
+
+					initLogger(*isDebugging, *pipe)
+
+					if *pipe {
+						return startPipeGame(w, h, v, *playerNum, ai, *ponder)
+					}
+
+					if *playerNum == 2 {
+						return startLocalMultiGame(w, h, v, *evalBar, *name, *record)
+					}
+
+					return startLocalSingleGame(w, h, v, ai, *ponder, *evalBar, *name, *record)
+				}
+			},
+		},
+		{
+			Name:    "serve",
+			Summary: "Host an online game, or run an SSH server",
+			Flags: func(fs *flag.FlagSet) func(args []string) error {
+				board := addBoardFlags(fs, cfg)
+				port := fs.Int("port", cfg.Port, "Specify game server's port")
+				level := fs.Int("level", cfg.AiLevel, "Strength of the AI on the SSH server, from 1 (random) to 5")
+				name := fs.String("name", cfg.PlayerName, "Your name shown in the game")
+				sshServer := fs.Bool("ssh", false, "Start an SSH server to play without installing the game")
+				sshPort := fs.Int("ssh-port", DEFAULT_SSH_PORT, "Specify SSH server's port")
+				sshHostKey := fs.String("ssh-host-key", DEFAULT_SSH_HOST_KEY, "Host key file of SSH server. Generated if it doesn't exist")
+				isDebugging := fs.Bool("d", false, "Debug info")
+
+				return func(args []string) error {
+					if err := noArgs(args); err != nil {
+						return err
+					}
+					w, h, v, err := board.parse()
+					if err != nil {
+						return err
+					}
+					if err := validatePort(*port); err != nil {
+						return err
+					}
+					if err := validatePort(*sshPort); err != nil {
+						return err
+					}
+					if err := validateAiLevel(*level); err != nil {
+						return err
+					}
+
+					initLogger(*isDebugging, false)
+
+					if *sshServer {
+						return startSshServer(w, h, v, *sshPort, *sshHostKey, *level)
+					}
+
+					startHostClient(NewRectBoard(w, h, v), *port, *name)
+					return nil
+				}
+			},
+		},
+		{
+			Name:    "join",
+			Args:    "[URL]",
+			Summary: "Join an online game hosted at URL, or server_url in the config",
+			Flags: func(fs *flag.FlagSet) func(args []string) error {
+				port := fs.Int("port", cfg.Port, "Specify game server's port, used if URL has no port")
+				name := fs.String("name", cfg.PlayerName, "Your name shown in the game")
+				pipe := fs.Bool("pipe", false, "Read commands from stdin and write the board to stdout")
+				isDebugging := fs.Bool("d", false, "Debug info")
+
+				return func(args []string) error {
+					if len(args) > 1 {
+						return usageErrorf("join needs only one URL")
+					}
+
+					url := cfg.ServerUrl
+					if len(args) == 1 {
+						url = args[0]
+					}
+					if url == "" {
+						return usageErrorf("join needs a URL, or server_url in the config")
+					}
+					if !strings.Contains(url, "://") {
+						return usageErrorf("URL must start with http://, https://, ws:// or wss://")
+					}
+					if err := validatePort(*port); err != nil {
+						return err
+					}
+
+					initLogger(*isDebugging, *pipe)
+
+					if *pipe {
+						return startPipeGuest(url, *port)
+					}
+
+					startGuestClient(url, *port, *name)
+					return nil
+				}
+			},
+		},
+		{
+			Name:    "config",
+			Args:    "show",
+			Summary: "Show the settings from the config file and the environment variables",
+			Flags: func(fs *flag.FlagSet) func(args []string) error {
+				return func(args []string) error {
+					if len(args) != 1 || args[0] != "show" {
+						return usageErrorf("config needs a subcommand: show")
+					}
+					cfg.Print(os.Stdout)
+					return nil
+				}
+			},
+		},
+		{
+			Name:    "analyze",
+			Args:    "[FILE]",
+			Summary: "Show the AI evaluation of every legal move in a position of a record or -board",
+			Flags: func(fs *flag.FlagSet) func(args []string) error {
+				moveN := fs.Int("move", -1, "Analyze the position after this number of moves in the record. The last position if negative")
+				board := fs.String("board", "", "Board rows of X, O and - separated by /, e.g., ---/-XO/--- (instead of FILE)")
+				turn := fs.String("turn", "black", "Player to move on -board: black or white")
+				position := fs.String("position", "", "Position notation, e.g., \"4x4 -----XO--OX----- X\" (instead of FILE and -board)")
+				depth := fs.Int("depth", DEFAULT_ANALYSIS_DEPTH, "Maximum search depth")
+				timeLimit := fs.Duration("time", DEFAULT_ANALYSIS_TIME, "Time limit of the search. The search stops at the last finished depth")
+				isDebugging := fs.Bool("d", false, "Debug info")
+
+				return func(args []string) error {
+					if *depth < 1 {
+						return usageErrorf("-depth must be 1 or more")
+					}
+
+					var b *Board
+
+					if *position != "" {
+						if *board != "" {
+							return usageErrorf("-position and -board can't be used together")
+						}
+						if err := noArgs(args); err != nil {
+							return err
+						}
+
+						var err error
+						b, err = ParsePosition(*position)
+						if err != nil {
+							return usageErrorf("%s", err)
+						}
+					} else if *board != "" {
+						if err := noArgs(args); err != nil {
+							return err
+						}
+
+						t, err := parseColour(*turn)
+						if err != nil {
+							return usageErrorf("-turn must be black or white")
+						}
+
+						b, err = parseBoardRows(*board, t)
+						if err != nil {
+							return usageErrorf("%s", err)
+						}
+					} else {
+						path, err := fileArg(args)
+						if err != nil {
+							return err
+						}
+
+						b, err = recordPosition(path, *moveN)
+						if err != nil {
+							return err
+						}
+					}
+
+					initLogger(*isDebugging, false)
+
+					return startAnalyze(b, *depth, *timeLimit)
+				}
+			},
+		},
+		{
+			Name:    "replay",
+			Args:    "FILE",
+			Summary: "Step through a saved game",
+			Flags: func(fs *flag.FlagSet) func(args []string) error {
+				eval := fs.Bool("eval", false, "Analyze each move with the AI and flag blunders")
+				isDebugging := fs.Bool("d", false, "Debug info")
+
+				return func(args []string) error {
+					path, err := fileArg(args)
+					if err != nil {
+						return err
+					}
+
+					r, err := LoadRecord(path)
+					if err != nil {
+						return err
+					}
+
+					initLogger(*isDebugging, false)
+
+					return startReplay(r, *eval)
+				}
+			},
+		},
+		{
+			Name:    "review",
+			Args:    "FILE",
+			Summary: "Review every move of a saved game and find the mistakes",
+			Flags: func(fs *flag.FlagSet) func(args []string) error {
+				asJson := fs.Bool("json", false, "Write the report in JSON")
+				output := fs.String("o", "", "Write the report to the file instead of stdout")
+				depth := fs.Int("depth", uiAnalysisDepth, "Maximum search depth for each move")
+				timeLimit := fs.Duration("time", time.Second, "Time limit of the search for each move")
+				isDebugging := fs.Bool("d", false, "Debug info")
+
+				return func(args []string) error {
+					path, err := fileArg(args)
+					if err != nil {
+						return err
+					}
+					if *depth < 1 {
+						return usageErrorf("-depth must be 1 or more")
+					}
+
+					r, err := LoadRecord(path)
+					if err != nil {
+						return err
+					}
+
+					initLogger(*isDebugging, false)
+
+					return startReview(r, *depth, *timeLimit, *asJson, *output)
+				}
+			},
+		},
+		{
+			Name:    "tournament",
+			Args:    "ENGINE ENGINE...",
+			Summary: "Play AI engines against each other, e.g. \"level=3\" \"depth=5,weights=w.json,name=new\" \"random\" \"mcts,iterations=3000\"",
+			Flags: func(fs *flag.FlagSet) func(args []string) error {
+				n := fs.Int("n", cfg.BoardSize, "Dimension of the board")
+				games := fs.Int("games", DEFAULT_TOURNAMENT_GAMES, "Number of games for each pair of engines, half with each colour")
+				openingPlies := fs.Int("openings", DEFAULT_OPENING_PLIES, "Number of random moves at the start of each game")
+				parallel := fs.Int("parallel", runtime.NumCPU(), "Number of games played at the same time")
+				seed := fs.Int64("seed", 0, "Seed of the random openings. 0 for a random seed")
+				csvPath := fs.String("csv", "", "Write the result of each game to the CSV file")
+				recordDir := fs.String("records", "", "Save the record of each game to the directory")
+				isDebugging := fs.Bool("d", false, "Debug info")
+
+				return func(args []string) error {
+					if len(args) < 2 {
+						return usageErrorf("needs two or more ENGINEs")
+					}
+					if err := validateBoardSize(*n); err != nil {
+						return err
+					}
+					if *games < 2 || *games%2 != 0 {
+						return usageErrorf("-games must be an even number of 2 or more")
+					}
+					if *openingPlies < 0 {
+						return usageErrorf("-openings must be 0 or more")
+					}
+					if *parallel < 1 {
+						return usageErrorf("-parallel must be 1 or more")
+					}
+
+					engines := make([]EngineConfig, 0, len(args))
+					for _, arg := range args {
+						e, err := ParseEngineConfig(arg)
+						if err != nil {
+							return usageErrorf("%s", err)
+						}
+						engines = append(engines, e)
+					}
+
+					if *seed == 0 {
+						*seed = time.Now().UnixNano()
+					}
+
+					initLogger(*isDebugging, false)
+
+					t := Tournament{
+						N:            *n,
+						Engines:      engines,
+						Games:        *games,
+						OpeningPlies: *openingPlies,
+						Parallel:     *parallel,
+						Seed:         *seed,
+						RecordDir:    *recordDir,
+					}
+
+					return startTournament(t, *csvPath)
+				}
+			},
+		},
+		{
+			Name:    "tune",
+			Args:    "[RECORD...]",
+			Summary: "Fit the evaluation weights of the AI to self-play games and saved games",
+			Flags: func(fs *flag.FlagSet) func(args []string) error {
+				n := fs.Int("n", cfg.BoardSize, "Dimension of the board")
+				games := fs.Int("games", DEFAULT_TUNE_GAMES, "Number of self-play games")
+				depth := fs.Int("depth", DEFAULT_TUNE_DEPTH, "Search depth of the self-play")
+				openingPlies := fs.Int("openings", DEFAULT_OPENING_PLIES, "Number of random moves at the start of each self-play game")
+				solveEmpties := fs.Int("solve", DEFAULT_SOLVE_EMPTIES, "Solve positions with this number of empty cells or fewer to the end")
+				ridge := fs.Float64("ridge", DEFAULT_RIDGE, "Strength of the ridge regularization")
+				seed := fs.Int64("seed", 1, "Seed of the self-play")
+				patterns := fs.Bool("patterns", false, "Learn the score tables of the lines instead of the cell scores")
+				phases := fs.Int("phases", DEFAULT_PATTERN_PHASES, "Number of game phases of the line tables")
+				epochs := fs.Int("epochs", DEFAULT_PATTERN_EPOCHS, "Number of passes over the positions to learn the line tables")
+				rate := fs.Float64("rate", DEFAULT_PATTERN_RATE, "Learning rate of the line tables")
+				output := fs.String("o", "", "File to write (default \"weights.json\", or \"patterns.json\" with -patterns). The tables of other board sizes in it are kept")
+				isDebugging := fs.Bool("d", false, "Debug info")
+
+				return func(args []string) error {
+					if err := validateBoardSize(*n); err != nil {
+						return err
+					}
+					if *games < 0 {
+						return usageErrorf("-games must be 0 or more")
+					}
+					if *games == 0 && len(args) == 0 {
+						return usageErrorf("needs self-play games or RECORDs")
+					}
+					if *depth < 1 {
+						return usageErrorf("-depth must be 1 or more")
+					}
+					if *openingPlies < 0 {
+						return usageErrorf("-openings must be 0 or more")
+					}
+					if *ridge < 0 {
+						return usageErrorf("-ridge must be 0 or more")
+					}
+					if *phases < 1 {
+						return usageErrorf("-phases must be 1 or more")
+					}
+					if *epochs < 1 {
+						return usageErrorf("-epochs must be 1 or more")
+					}
+					if *rate <= 0 {
+						return usageErrorf("-rate must be more than 0")
+					}
+
+					if *output == "" {
+						*output = "weights.json"
+						if *patterns {
+							*output = "patterns.json"
+						}
+					}
+
+					records := make([]Record, 0, len(args))
+					for _, path := range args {
+						r, err := LoadRecord(path)
+						if err != nil {
+							return err
+						}
+						records = append(records, r)
+					}
+
+					initLogger(*isDebugging, false)
+
+					t := Tuner{
+						N:            *n,
+						Games:        *games,
+						Depth:        *depth,
+						OpeningPlies: *openingPlies,
+						SolveEmpties: *solveEmpties,
+						Ridge:        *ridge,
+						Seed:         *seed,
+						Patterns:     *patterns,
+						Phases:       *phases,
+						Epochs:       *epochs,
+						Rate:         *rate,
+					}
+
+					return startTune(t, records, *output)
+				}
+			},
+		},
+		{
+			Name:    "bench",
+			Summary: "Measure the speed of the board and the AI",
+			Flags: func(fs *flag.FlagSet) func(args []string) error {
+				n := fs.Int("n", DEFAULT_N, "Dimension of the board")
+				depth := fs.Int("depth", DEFAULT_PERFT_DEPTH, "Maximum depth of perft")
+				searchDepth := fs.Int("search-depth", DEFAULT_SEARCH_DEPTH, "Depth of the search benchmark. 0 to skip it")
+				threads := fs.Int("threads", 1, "Number of moves searched at the same time in the search benchmark")
+				isDebugging := fs.Bool("d", false, "Debug info")
+
+				return func(args []string) error {
+					if err := noArgs(args); err != nil {
+						return err
+					}
+					if err := validateBoardSize(*n); err != nil {
+						return err
+					}
+					if *depth < 1 {
+						return usageErrorf("-depth must be 1 or more")
+					}
+					if *searchDepth < 0 {
+						return usageErrorf("-search-depth must be 0 or more")
+					}
+					if *threads < 1 {
+						return usageErrorf("-threads must be 1 or more")
+					}
+
+					initLogger(*isDebugging, false)
+
+					return startBench(*n, *depth, *searchDepth, *threads)
+				}
+			},
+		},
+	}
+}
+
+func findCommand(name string, cfg *Config) *Command {
+	for _, cmd := range commands(cfg) {
+		if cmd.Name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// runCli runs the subcommand with the config as the default options.
+// Arguments starting with a flag are the old style options for "play"
+func runCli(args []string, cfg *Config, stderr io.Writer) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		converted, err := convertLegacyArgs(args, cfg, stderr)
+		if err != nil {
+			return err
+		}
+		args = converted
+	}
+
+	name := args[0]
+
+	if name == "help" {
+		if len(args) > 1 && findCommand(args[1], cfg) != nil {
+			fs, _ := newFlagSet(findCommand(args[1], cfg), stderr)
+			fs.Usage()
+			return nil
+		}
+		printUsage(stderr, cfg)
+		return nil
+	}
+
+	cmd := findCommand(name, cfg)
+	if cmd == nil {
+		printUsage(stderr, cfg)
+		return usageErrorf("unknown command %q", name)
+	}
+
+	fs, run := newFlagSet(cmd, stderr)
+
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return &usageError{err.Error()}
+	}
+
+	err := run(fs.Args())
+
+	var uErr *usageError
+	if errors.As(err, &uErr) {
+		fmt.Fprintf(stderr, "reversi %s: %s\n", cmd.Name, uErr.msg)
+		fs.Usage()
+	}
+
+	return err
+}
+
+// newFlagSet returns the flags of the command and the function to run it
+func newFlagSet(cmd *Command, output io.Writer) (*flag.FlagSet, func(args []string) error) {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	fs.Usage = func() {
+		usage := strings.TrimSpace(fmt.Sprintf("reversi %s [options] %s", cmd.Name, cmd.Args))
+		fmt.Fprintf(output, "Usage: %s\n\n%s\n\nOptions:\n", usage, cmd.Summary)
+		fs.PrintDefaults()
+	}
+
+	run := cmd.Flags(fs)
+
+	return fs, run
+}
+
+func printUsage(w io.Writer, cfg *Config) {
+	fmt.Fprintln(w, "Usage: reversi <command> [options] [arguments]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands(cfg) {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.Name, cmd.Summary)
+	}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Run 'reversi help <command>' for the options of the command.")
+	fmt.Fprintln(w, "Without a command, the options are the same as the older versions, e.g., 'reversi -n 6 -p 2'.")
+}
+
+// convertLegacyArgs converts the old style options (e.g., "-s -n 6") to a subcommand (e.g., "serve -n 6")
+func convertLegacyArgs(args []string, cfg *Config, output io.Writer) ([]string, error) {
+	fs := flag.NewFlagSet("reversi", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() { printUsage(output, cfg) }
+
+	n := fs.Int("n", cfg.BoardSize, "Dimension of the board")
+	playerNum := fs.Int("p", 1, "1 for Single Play, 2 for 2 Players")
+	server := fs.Bool("s", false, "Start game with server")
+	isDebugging := fs.Bool("d", false, "Debug info")
+	url := fs.String("url", "", "Specify game server url to connect")
+	port := fs.Int("port", cfg.Port, "Specify game server's port")
+	pipe := fs.Bool("pipe", false, "Read commands from stdin and write the board to stdout")
+	sshServer := fs.Bool("ssh", false, "Start an SSH server to play without installing the game")
+	sshPort := fs.Int("ssh-port", DEFAULT_SSH_PORT, "Specify SSH server's port")
+	sshHostKey := fs.String("ssh-host-key", DEFAULT_SSH_HOST_KEY, "Host key file of SSH server")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return []string{"help"}, nil
+		}
+		return nil, &usageError{err.Error()}
+	}
+
+	if fs.NArg() > 0 {
+		return nil, usageErrorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	common := []string{fmt.Sprintf("-d=%t", *isDebugging)}
+
+	// the same precedence as the older versions: server, url, then players
+	switch {
+	case *server || *sshServer:
+		return append([]string{
+			"serve",
+			fmt.Sprintf("-n=%d", *n),
+			fmt.Sprintf("-port=%d", *port),
+			fmt.Sprintf("-ssh=%t", *sshServer),
+			fmt.Sprintf("-ssh-port=%d", *sshPort),
+			fmt.Sprintf("-ssh-host-key=%s", *sshHostKey),
+		}, common...), nil
+
+	case *url != "":
+		return append([]string{
+			"join",
+			fmt.Sprintf("-port=%d", *port),
+			fmt.Sprintf("-pipe=%t", *pipe),
+		}, append(common, *url)...), nil
+
+	default:
+		return append([]string{
+			"play",
+			fmt.Sprintf("-n=%d", *n),
+			fmt.Sprintf("-p=%d", *playerNum),
+			fmt.Sprintf("-pipe=%t", *pipe),
+		}, common...), nil
+	}
+}
+
+func initLogger(isDebugging bool, pipe bool) {
+	logLevel := slog.LevelError
+	if isDebugging {
+		logLevel = slog.LevelDebug
+	}
+
+	if pipe {
+		// stdout is for the board dumps
+		logger = NewStderrLogger(logLevel)
+	} else {
+		logger = NewLogger(logLevel)
+	}
+}
+
+func noArgs(args []string) error {
+	if len(args) > 0 {
+		return usageErrorf("unexpected argument %q", args[0])
+	}
+	return nil
+}
+
+// fileArg returns the only argument, which must be an existing file
+func fileArg(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", usageErrorf("needs exactly one FILE")
+	}
+
+	if _, err := os.Stat(args[0]); err != nil {
+		return "", usageErrorf("can't open %s", args[0])
+	}
+
+	return args[0], nil
+}
+
+func validateBoardSize(n int) error {
+	if n < MIN_N || n > MAX_N {
+		return usageErrorf("-n must be between %d and %d", MIN_N, MAX_N)
+	}
+	return nil
+}
+
+// boardFlags are the flags of the board, shared by play and serve
+type boardFlags struct {
+	n         *int
+	width     *int
+	height    *int
+	rule      *string
+	opening   *string
+	holes     *int
+	holesFile *string
+	position  *string
+}
+
+func addBoardFlags(fs *flag.FlagSet, cfg *Config) boardFlags {
+	return boardFlags{
+		n:         fs.Int("n", cfg.BoardSize, "Dimension of the board"),
+		width:     fs.Int("width", 0, "Width of a rectangular board, -n if not set"),
+		height:    fs.Int("height", 0, "Height of a rectangular board, -n if not set"),
+		rule:      fs.String("rule", string(RuleStandard), "standard (more discs win) or anti (fewer discs win)"),
+		opening:   fs.String("opening", string(OpeningStandard), "Start of the board: standard, parallel, random, or free (players place the first four discs)"),
+		holes:     fs.Int("holes", 0, "Number of random holes no one can place on, symmetrical about the centre"),
+		holesFile: fs.String("holes-file", "", "File of the holes in rows like \"--#--#--\", where # is a hole"),
+		position:  fs.String("position", "", "Start from the position notation, e.g., \"4x4 -----XO--OX----- X\". The board size comes from it"),
+	}
+}
+
+// parse validates the flags, and returns the width, the height and the variant of the board
+func (f boardFlags) parse() (int, int, Variant, error) {
+	if err := validateBoardSize(*f.n); err != nil {
+		return 0, 0, Variant{}, err
+	}
+	w, h, err := boardSides(*f.n, *f.width, *f.height)
+	if err != nil {
+		return 0, 0, Variant{}, err
+	}
+
+	v, err := parseVariant(*f.rule, *f.opening)
+	if err != nil {
+		return 0, 0, Variant{}, usageErrorf("%s", err)
+	}
+
+	if *f.holes != 0 && *f.holesFile != "" {
+		return 0, 0, Variant{}, usageErrorf("-holes and -holes-file can't be used together")
+	}
+
+	if *f.holes != 0 {
+		v.Holes, err = randomHoles(w, h, *f.holes, v, rand.New(rand.NewSource(time.Now().UnixNano())))
+		if err != nil {
+			return 0, 0, Variant{}, usageErrorf("%s", err)
+		}
+	}
+
+	if *f.holesFile != "" {
+		v.Holes, err = LoadHoles(*f.holesFile, w, h)
+		if err != nil {
+			return 0, 0, Variant{}, err
+		}
+		if !playableStart(w, h, v) {
+			return 0, 0, Variant{}, fmt.Errorf("Black can't place at the start with the holes of %s", *f.holesFile)
+		}
+	}
+
+	if *f.position != "" {
+		if *f.holes != 0 || *f.holesFile != "" || v.Opening != OpeningStandard {
+			return 0, 0, Variant{}, usageErrorf("-position can't be used with -holes, -holes-file or -opening")
+		}
+
+		b, err := parseStartPosition(*f.position)
+		if err != nil {
+			return 0, 0, Variant{}, usageErrorf("%s", err)
+		}
+		w, h = b.W, b.H
+		v.Position, v.Holes = b.Variant.Position, b.Variant.Holes
+	}
+
+	return w, h, v, nil
+}
+
+// boardSides returns the width and the height of the board. A side not set is the same as -n
+func boardSides(n, w, h int) (int, int, error) {
+	if w == 0 {
+		w = n
+	}
+	if h == 0 {
+		h = n
+	}
+
+	if w < MIN_N || w > MAX_N {
+		return 0, 0, usageErrorf("-width must be between %d and %d", MIN_N, MAX_N)
+	}
+	if h < MIN_N || h > MAX_N {
+		return 0, 0, usageErrorf("-height must be between %d and %d", MIN_N, MAX_N)
+	}
+
+	return w, h, nil
+}
+
+func validateAiLevel(level int) error {
+	if level < MIN_AI_LEVEL || level > MAX_AI_LEVEL {
+		return usageErrorf("-level must be between %d and %d", MIN_AI_LEVEL, MAX_AI_LEVEL)
+	}
+	return nil
+}
+
+func validatePort(port int) error {
+	if port < 1 || port > 65535 {
+		return usageErrorf("port must be between 1 and 65535")
+	}
+	return nil
+}
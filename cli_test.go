@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCliConvertLegacyArgs(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	cases := []struct {
+		Input []string
+		Want  []string
+	}{
+		{
+			[]string{},
+			[]string{"play", "-n=8", "-p=1", "-pipe=false", "-d=false"},
+		},
+		{
+			[]string{"-n", "6", "-p", "2"},
+			[]string{"play", "-n=6", "-p=2", "-pipe=false", "-d=false"},
+		},
+		{
+			[]string{"-url", "http://example.com", "-p", "2", "-d"},
+			[]string{"join", "-port=4696", "-pipe=false", "-d=true", "http://example.com"},
+		},
+		{
+			// server has the priority over url
+			[]string{"-s", "-url", "http://example.com", "-port", "80"},
+			[]string{"serve", "-n=8", "-port=80", "-ssh=false", "-ssh-port=2222", "-ssh-host-key=reversi_ssh_host_key", "-d=false"},
+		},
+	}
+
+	for _, c := range cases {
+		cfg := defaultConfig()
+		got, err := convertLegacyArgs(c.Input, &cfg, &bytes.Buffer{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, c.Want, got)
+	}
+}
+
+func TestCliValidation(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	cfg := defaultConfig()
+
+	cases := [][]string{
+		{"play", "-p", "3"},
+		{"play", "-n", "2"},
+		{"play", "-width", "11"},
+		{"play", "-n", "6", "-height", "2"},
+		{"play", "-position", "4x4 -----XO--OX----- Z"},
+		{"play", "-position", "4x4 -----XO--OX----- X", "-holes", "2"},
+		{"serve", "-position", "-----XO--OX----- X"},
+		{"serve", "-width", "11"},
+		{"serve", "-rule", "dance"},
+		{"serve", "-holes", "2", "-holes-file", "holes.txt"},
+		{"analyze", "-position", "4x4 -----XO--OX----- X", "-board", "---/-XO/-OX"},
+		{"play", "extra"},
+		{"play", "-level", "6"},
+		{"serve", "-port", "0"},
+		{"join"},
+		{"join", "example.com"},
+		{"config"},
+		{"config", "edit"},
+		{"analyze"},
+		{"analyze", "-board", "--/--"},
+		{"analyze", "-board", "---/-XO/-OX", "-turn", "red"},
+		{"analyze", "-depth", "0", "game.txt"},
+		{"replay", "no_such_file"},
+		{"dance"},
+		{"-p", "3"},
+		{"-unknown"},
+	}
+
+	for _, args := range cases {
+		out := &bytes.Buffer{}
+		err := runCli(args, &cfg, out)
+
+		var uErr *usageError
+		assert.True(t, errors.As(err, &uErr), "%v should be a usage error", args)
+		assert.Contains(t, out.String(), "Usage: reversi")
+	}
+}
+
+func TestCliHelp(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	cfg := defaultConfig()
+
+	out := &bytes.Buffer{}
+	assert.Nil(t, runCli([]string{"help", "join"}, &cfg, out))
+	assert.Contains(t, out.String(), "Usage: reversi join [options] [URL]")
+
+	out = &bytes.Buffer{}
+	assert.Nil(t, runCli([]string{"serve", "-h"}, &cfg, out))
+	assert.Contains(t, out.String(), "-ssh-port")
+	assert.Contains(t, out.String(), "-holes-file")
+
+	out = &bytes.Buffer{}
+	assert.Nil(t, runCli([]string{"play", "-h"}, &cfg, out))
+	assert.Contains(t, out.String(), "-height")
+}
+
+func TestCliConfigAsDefaults(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	cfg := defaultConfig()
+	cfg.BoardSize = 6
+	cfg.Port = 8080
+
+	got, err := convertLegacyArgs([]string{"-s"}, &cfg, &bytes.Buffer{})
+	assert.Nil(t, err)
+	assert.Contains(t, got, "-n=6")
+	assert.Contains(t, got, "-port=8080")
+
+	// flags have the priority over the config
+	got, err = convertLegacyArgs([]string{"-n", "4"}, &cfg, &bytes.Buffer{})
+	assert.Nil(t, err)
+	assert.Contains(t, got, "-n=4")
+
+	out := &bytes.Buffer{}
+	assert.Nil(t, runCli([]string{"help", "play"}, &cfg, out))
+	assert.Contains(t, out.String(), "(default 6)")
+}
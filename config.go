@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	CONFIG_ENV_PREFIX = "REVERSI_"
+	CONFIG_DIR        = "go-reversi"
+	CONFIG_FILE       = "config.json"
+)
+
+// Config is the settings read from the config file and the environment variables.
+// The precedence is flags > env > file > defaults, and the flags use the config as their defaults
+type Config struct {
+	BoardSize   int         `json:"board_size"`
+	AiLevel     int         `json:"ai_level"`
+	Theme       string      `json:"theme"`
+	KeyBindings KeyBindings `json:"key_bindings"`
+	PlayerName  string      `json:"player_name"`
+	ServerUrl   string      `json:"server_url"`
+	Port        int         `json:"port"`
+	WeightsFile string      `json:"weights_file"` // the evaluation weights made by "reversi tune"
+	PatternFile string      `json:"pattern_file"` // the line tables made by "reversi tune -patterns"
+
+	Path    string            `json:"-"` // the config file, which may not exist
+	sources map[string]string // where each setting comes from
+}
+
+// the order to show the settings
+var configKeys = []string{
+	"board_size",
+	"ai_level",
+	"theme",
+	"player_name",
+	"server_url",
+	"port",
+	"weights_file",
+	"pattern_file",
+}
+
+func defaultConfig() Config {
+	cfg := Config{
+		BoardSize:   DEFAULT_N,
+		AiLevel:     DEFAULT_AI_LEVEL,
+		Theme:       DEFAULT_THEME,
+		KeyBindings: defaultKeyBindings(),
+		Port:        DEFAULT_PORT,
+		sources:     make(map[string]string),
+	}
+
+	for _, key := range cfg.allKeys() {
+		cfg.sources[key] = "default"
+	}
+
+	return cfg
+}
+
+// configPath returns $REVERSI_CONFIG, or config.json in the user config directory
+func configPath(getenv func(string) string) string {
+	if path := getenv(CONFIG_ENV_PREFIX + "CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, CONFIG_DIR, CONFIG_FILE)
+}
+
+// loadConfig reads the file if it exists, then applies the environment variables
+func loadConfig(path string, getenv func(string) string) (Config, error) {
+	cfg := defaultConfig()
+	cfg.Path = path
+
+	if err := cfg.readFile(path); err != nil {
+		return cfg, err
+	}
+
+	if err := cfg.readEnv(getenv); err != nil {
+		return cfg, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func (cfg *Config) readFile(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	bytes, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to read config: %w", err)
+	}
+
+	// decode into a map first to know which settings are in the file
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(bytes, &fields); err != nil {
+		return fmt.Errorf("Failed to parse config %s: %w", path, err)
+	}
+
+	// key bindings in the file replace only the actions they have
+	keyBindings := cfg.KeyBindings
+	cfg.KeyBindings = nil
+
+	if err := json.Unmarshal(bytes, cfg); err != nil {
+		return fmt.Errorf("Failed to parse config %s: %w", path, err)
+	}
+
+	for action, keys := range cfg.KeyBindings {
+		keyBindings[action] = keys
+		cfg.sources[keyBindingKey(action)] = "file"
+	}
+	cfg.KeyBindings = keyBindings
+
+	for _, key := range configKeys {
+		if _, ok := fields[key]; ok {
+			cfg.sources[key] = "file"
+		}
+	}
+
+	return nil
+}
+
+func (cfg *Config) readEnv(getenv func(string) string) error {
+	strs := map[string]*string{
+		"theme":        &cfg.Theme,
+		"player_name":  &cfg.PlayerName,
+		"server_url":   &cfg.ServerUrl,
+		"weights_file": &cfg.WeightsFile,
+		"pattern_file": &cfg.PatternFile,
+	}
+	ints := map[string]*int{
+		"board_size": &cfg.BoardSize,
+		"ai_level":   &cfg.AiLevel,
+		"port":       &cfg.Port,
+	}
+
+	for _, key := range configKeys {
+		name := configEnvName(key)
+		value := getenv(name)
+		if value == "" {
+			continue
+		}
+
+		if s, ok := strs[key]; ok {
+			*s = value
+		}
+
+		if i, ok := ints[key]; ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s must be a number: %q", name, value)
+			}
+			*i = n
+		}
+
+		cfg.sources[key] = "env " + name
+	}
+
+	for _, action := range keyActions {
+		name := configEnvName(keyBindingKey(action))
+		if value := getenv(name); value != "" {
+			cfg.KeyBindings[action] = value
+			cfg.sources[keyBindingKey(action)] = "env " + name
+		}
+	}
+
+	return nil
+}
+
+func (cfg *Config) validate() error {
+	if cfg.BoardSize < MIN_N || cfg.BoardSize > MAX_N {
+		return fmt.Errorf("board_size must be between %d and %d", MIN_N, MAX_N)
+	}
+
+	if cfg.AiLevel < MIN_AI_LEVEL || cfg.AiLevel > MAX_AI_LEVEL {
+		return fmt.Errorf("ai_level must be between %d and %d", MIN_AI_LEVEL, MAX_AI_LEVEL)
+	}
+
+	if _, ok := themes[cfg.Theme]; !ok {
+		return fmt.Errorf("theme must be one of %s", strings.Join(themeNames(), ", "))
+	}
+
+	if cfg.Port < 1 || cfg.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535")
+	}
+
+	return cfg.KeyBindings.validate()
+}
+
+// apply sets the theme and key bindings used by the display and the clients, and the weights used by the AI
+func (cfg *Config) apply() error {
+	keyBindings = cfg.KeyBindings
+
+	// the kept AIs are built with the old weights
+	resetAiPlayers()
+
+	aiWeights = nil
+	if cfg.WeightsFile != "" {
+		weights, err := LoadWeights(cfg.WeightsFile)
+		if err != nil {
+			return err
+		}
+		aiWeights = weights
+	}
+
+	aiPatterns = nil
+	if cfg.PatternFile != "" {
+		patterns, err := LoadPatternWeights(cfg.PatternFile)
+		if err != nil {
+			return err
+		}
+		aiPatterns = patterns
+	}
+
+	return applyTheme(cfg.Theme)
+}
+
+// Print writes the effective settings and where each one comes from
+func (cfg *Config) Print(w io.Writer) {
+	path := cfg.Path
+	if _, err := os.Stat(path); err != nil {
+		path += " (not found)"
+	}
+	fmt.Fprintf(w, "Config file: %s\n\n", path)
+
+	values := map[string]string{
+		"board_size":   strconv.Itoa(cfg.BoardSize),
+		"ai_level":     strconv.Itoa(cfg.AiLevel),
+		"theme":        cfg.Theme,
+		"player_name":  strconv.Quote(cfg.PlayerName),
+		"server_url":   strconv.Quote(cfg.ServerUrl),
+		"port":         strconv.Itoa(cfg.Port),
+		"weights_file": strconv.Quote(cfg.WeightsFile),
+		"pattern_file": strconv.Quote(cfg.PatternFile),
+	}
+	for _, action := range keyActions {
+		values[keyBindingKey(action)] = strconv.Quote(cfg.KeyBindings[action])
+	}
+
+	for _, key := range cfg.allKeys() {
+		fmt.Fprintf(w, "%-20s %-12s (%s)\n", key, values[key], cfg.sources[key])
+	}
+}
+
+func (cfg *Config) allKeys() []string {
+	keys := append([]string{}, configKeys...)
+	for _, action := range keyActions {
+		keys = append(keys, keyBindingKey(action))
+	}
+	return keys
+}
+
+// keyBindingKey returns the name of the key binding setting, e.g., "key_bindings.left"
+func keyBindingKey(action KeyAction) string {
+	return "key_bindings." + string(action)
+}
+
+// configEnvName returns the environment variable of the setting, e.g., REVERSI_BOARD_SIZE, REVERSI_KEY_LEFT
+func configEnvName(key string) string {
+	key = strings.Replace(key, "key_bindings.", "key_", 1)
+	return CONFIG_ENV_PREFIX + strings.ToUpper(key)
+}
+
+func themeNames() []string {
+	return []string{"classic", "ascii", "inverted"}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func envFrom(env map[string]string) func(string) string {
+	return func(key string) string { return env[key] }
+}
+
+func TestConfigDefaults(t *testing.T) {
+	cfg, err := loadConfig(filepath.Join(t.TempDir(), "none.json"), envFrom(nil))
+
+	assert.Nil(t, err)
+	assert.Equal(t, DEFAULT_N, cfg.BoardSize)
+	assert.Equal(t, DEFAULT_AI_LEVEL, cfg.AiLevel)
+	assert.Equal(t, DEFAULT_THEME, cfg.Theme)
+	assert.Equal(t, DEFAULT_PORT, cfg.Port)
+	assert.Equal(t, defaultKeyBindings(), cfg.KeyBindings)
+	assert.Equal(t, "default", cfg.sources["board_size"])
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, `{
+		"board_size": 6,
+		"ai_level": 2,
+		"player_name": "Alice",
+		"server_url": "http://example.com",
+		"key_bindings": {"place": "x"}
+	}`)
+
+	env := map[string]string{
+		"REVERSI_BOARD_SIZE": "4",
+		"REVERSI_THEME":      "ascii",
+		"REVERSI_KEY_QUIT":   "q",
+	}
+
+	cfg, err := loadConfig(path, envFrom(env))
+	assert.Nil(t, err)
+
+	// env > file > defaults
+	assert.Equal(t, 4, cfg.BoardSize)
+	assert.Equal(t, "env REVERSI_BOARD_SIZE", cfg.sources["board_size"])
+	assert.Equal(t, 2, cfg.AiLevel)
+	assert.Equal(t, "file", cfg.sources["ai_level"])
+	assert.Equal(t, "ascii", cfg.Theme)
+	assert.Equal(t, "Alice", cfg.PlayerName)
+	assert.Equal(t, "http://example.com", cfg.ServerUrl)
+	assert.Equal(t, DEFAULT_PORT, cfg.Port)
+	assert.Equal(t, "default", cfg.sources["port"])
+
+	// key bindings in the file and env replace only their actions
+	assert.Equal(t, "x", cfg.KeyBindings[ActionPlace])
+	assert.Equal(t, "q", cfg.KeyBindings[ActionQuit])
+	assert.Equal(t, "ah", cfg.KeyBindings[ActionLeft])
+	assert.Equal(t, "env REVERSI_KEY_QUIT", cfg.sources["key_bindings.quit"])
+}
+
+func TestConfigInvalid(t *testing.T) {
+	cases := []struct {
+		File string
+		Env  map[string]string
+	}{
+		{`{"board_size": 6`, nil},
+		{`{"board_size": 11}`, nil},
+		{`{"ai_level": 0}`, nil},
+		{`{"theme": "neon"}`, nil},
+		{`{"key_bindings": {"jump": "j"}}`, nil},
+		// "a" is used for left
+		{`{"key_bindings": {"place": "a"}}`, nil},
+		{`{}`, map[string]string{"REVERSI_PORT": "http"}},
+		{`{}`, map[string]string{"REVERSI_PORT": "70000"}},
+	}
+
+	for _, c := range cases {
+		_, err := loadConfig(writeConfig(t, c.File), envFrom(c.Env))
+		assert.NotNil(t, err, "%s %v should be invalid", c.File, c.Env)
+	}
+}
+
+func TestConfigPath(t *testing.T) {
+	env := map[string]string{"REVERSI_CONFIG": "/tmp/reversi.json"}
+	assert.Equal(t, "/tmp/reversi.json", configPath(envFrom(env)))
+
+	assert.Contains(t, configPath(envFrom(nil)), filepath.Join("go-reversi", "config.json"))
+}
+
+func TestConfigPrint(t *testing.T) {
+	path := writeConfig(t, `{"player_name": "Alice"}`)
+
+	cfg, err := loadConfig(path, envFrom(map[string]string{"REVERSI_PORT": "8080"}))
+	assert.Nil(t, err)
+
+	out := &bytes.Buffer{}
+	cfg.Print(out)
+
+	assert.Contains(t, out.String(), "Config file: "+path+"\n")
+	assert.Regexp(t, `player_name +"Alice" +\(file\)`, out.String())
+	assert.Regexp(t, `port +8080 +\(env REVERSI_PORT\)`, out.String())
+	assert.Regexp(t, `key_bindings.place +" " +\(default\)`, out.String())
+}
+
+func TestConfigApply(t *testing.T) {
+	defer func() {
+		keyBindings = defaultKeyBindings()
+		applyTheme(DEFAULT_THEME)
+	}()
+
+	cfg := defaultConfig()
+	cfg.Theme = "ascii"
+	cfg.KeyBindings[ActionPlace] = "x"
+	assert.Nil(t, cfg.apply())
+
+	assert.Equal(t, "x", BlackString)
+	assert.Equal(t, "x", Black.String())
+	assert.Equal(t, ActionPlace, keyAction("x"))
+	assert.Equal(t, ActionNone, keyAction(" "))
+	assert.Equal(t, "x", keyLabel(ActionPlace))
+
+	// Ctrl + C always quits
+	assert.Equal(t, ActionQuit, keyAction("\x03"))
+}
+
+func TestConfigApplyWeights(t *testing.T) {
+	defer func() { aiWeights = nil }()
+
+	path := filepath.Join(t.TempDir(), "weights.json")
+	Weights{4: {{9, 0, 0, 9}, {0, 1, 1, 0}, {0, 1, 1, 0}, {9, 0, 0, 9}}}.Save(path)
+
+	cfg, err := loadConfig("", envFrom(map[string]string{"REVERSI_WEIGHTS_FILE": path}))
+	assert.Nil(t, err)
+	assert.Nil(t, cfg.apply())
+
+	assert.Equal(t, []int{9, 0, 0, 9}, cellScores(4, 4)[0])
+	assert.Equal(t, cellScore6, cellScores(6, 6))
+
+	cfg.WeightsFile = filepath.Join(t.TempDir(), "missing.json")
+	assert.Error(t, cfg.apply())
+}
+
+func TestConfigApplyPatterns(t *testing.T) {
+	defer func() { aiPatterns = nil }()
+
+	path := filepath.Join(t.TempDir(), "patterns.json")
+	PatternWeights{4: discDiffTables(4, 1)}.Save(path)
+
+	cfg, err := loadConfig("", envFrom(map[string]string{"REVERSI_PATTERN_FILE": path}))
+	assert.Nil(t, err)
+	assert.Nil(t, cfg.apply())
+
+	assert.NotNil(t, NewAiPlayer(4).patterns)
+	assert.Equal(t, "env REVERSI_PATTERN_FILE", cfg.sources["pattern_file"])
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"context"
+	_ "embed"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -13,6 +15,11 @@ import (
 
 var _ = time.Second //TODO: debugging
 
+// single page client for browsers, speaking the same protocol as OnlineGuestConnection
+//
+//go:embed web/index.html
+var webClientPage []byte
+
 // host a game server
 type OnlineHostConnection struct {
 	gameCh       chan Game
@@ -21,7 +28,9 @@ type OnlineHostConnection struct {
 	Port         int
 	conn         *websocket.Conn
 	server       *http.Server
-	isConnActive bool
+	isConnActive *atomic.Bool // read and written by the handler, the sender and the receiver
+	hasGuest     *atomic.Bool // set by the first guest, so that the others are turned away
+	writeMu      *sync.Mutex  // the conn allows one writer at a time
 }
 
 func NewOnlineHostConnection(
@@ -35,7 +44,9 @@ func NewOnlineHostConnection(
 		cmdCh:        cmdCh,
 		quitCh:       quitCh,
 		Port:         port,
-		isConnActive: false,
+		isConnActive: &atomic.Bool{},
+		hasGuest:     &atomic.Bool{},
+		writeMu:      &sync.Mutex{},
 	}
 
 	return conn
@@ -52,21 +63,38 @@ func (c *OnlineHostConnection) Run() error {
 	handler := func(w http.ResponseWriter, r *http.Request) {
 		logger.Debug("Handler started")
 
+		// browsers get the web client, which connects back with websocket
+		if !websocket.IsWebSocketUpgrade(r) {
+			serveWebClient(w, r)
+			return
+		}
+
+		// a game has one guest
+		if !c.hasGuest.CompareAndSwap(false, true) {
+			http.Error(w, "A guest is already connected", http.StatusConflict)
+			return
+		}
+
 		// establish websocket connection
 		upgrader := websocket.Upgrader{}
 		conn, err := upgrader.Upgrade(w, r, nil)
 		if err != nil {
-			// TODO: handle error properly
 			logger.Error("Online host connection error", slog.Any("err", err))
+			// the next guest can try again
+			c.hasGuest.Store(false)
+			return
 		}
 
 		c.conn = conn
 
+		// active before the receiver starts, so that it doesn't stop at once
+		c.isConnActive.Store(true)
+		connectedCh <- true
+		logger.Debug("Host conn established")
+
 		// Receive command from guest
 		go c.handleReceive()
 
-		connectedCh <- true
-
 		// Send game info to guest
 		c.handleSend()
 
@@ -87,40 +115,46 @@ func (c *OnlineHostConnection) Run() error {
 	return nil
 }
 
+func serveWebClient(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(webClientPage)
+}
+
 func (c *OnlineHostConnection) waitUntilConnIsReady(connectedCh chan bool) {
 	// consume game chan until connection is made
 	// push back the last game to send via conn
 	var lastGameSent Game
 
-	for !c.isConnActive {
+	for connected := false; !connected; {
 		select {
-		case c.isConnActive = <-connectedCh:
+		case connected = <-connectedCh:
 			c.gameCh <- lastGameSent
 			close(connectedCh)
 		case g := <-c.gameCh:
 			lastGameSent = g
-			if g.State == Quit {
-				c.isConnActive = false
-			}
 		}
 	}
-
-	logger.Debug("Host conn established")
 }
 
 func (c *OnlineHostConnection) handleReceive() {
 	for {
-		if !c.isConnActive {
+		if !c.isConnActive.Load() {
 			// quit listening if the conn is not active
 			break
 		}
 
 		cmd := GameCommand{}
 		if err := c.conn.ReadJSON(&cmd); err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway) {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
 				logger.Error("Host WebSocket Error", slog.Any("err", err))
-				break
 			}
+			// the conn can't be read any more once it fails
+			break
 		}
 
 		logger.Debug("Command received", slog.Any("cmd", cmd))
@@ -135,16 +169,14 @@ func (c *OnlineHostConnection) handleReceive() {
 }
 
 func (c *OnlineHostConnection) handleSend() {
-	var mu sync.Mutex
-
 	writeWithMutex := func(g Game) error {
-		mu.Lock()
-		defer mu.Unlock()
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
 		return c.conn.WriteJSON(g)
 	}
 
 	for g := range c.gameCh {
-		if !c.isConnActive {
+		if !c.isConnActive.Load() {
 			// if connection is not active, discard game
 			continue
 		}
@@ -152,7 +184,7 @@ func (c *OnlineHostConnection) handleSend() {
 		logger.Debug("Game received", slog.String("g", g.State.String()))
 		if err := writeWithMutex(g); err != nil {
 			logger.Error("Error on write", slog.Any("err", err))
-			c.isConnActive = false
+			c.isConnActive.Store(false)
 		}
 
 		if g.State == Quit {
@@ -162,8 +194,9 @@ func (c *OnlineHostConnection) handleSend() {
 }
 
 func (c *OnlineHostConnection) closeWebsocket() {
-	if c.isConnActive {
-		c.isConnActive = false
+	if c.isConnActive.CompareAndSwap(true, false) {
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
 
 		if err := c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")); err != nil {
 			logger.Error("Error sending close message: %v", slog.Any("err", err))
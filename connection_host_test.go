@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"log/slog"
+	"net"
+	"net/http"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -12,6 +16,17 @@ import (
 
 var muTest sync.Mutex
 
+// freePort returns a port no one is listening on, chosen by listening on port 0
+func freePort(t *testing.T) int {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
 func TestConnectionHostSendCommand(t *testing.T) {
 	logger = NewLogger(slog.LevelInfo)
 
@@ -79,3 +94,88 @@ func TestConnectionHostSendCommand(t *testing.T) {
 
 	assert.Equal(t, true, true)
 }
+
+func TestConnectionHostServesWebClient(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	muTest.Lock()
+	defer func() {
+		muTest.Unlock()
+		// wait for server to shut down
+		time.Sleep(50 * time.Millisecond)
+	}()
+
+	gameCh := make(chan Game)
+	cmdCh := make(chan GameCommand)
+	quitCh := make(chan bool)
+
+	port := freePort(t)
+	hostConn := NewOnlineHostConnection(gameCh, cmdCh, quitCh, port)
+
+	go hostConn.Run()
+
+	b := NewBoard(3)
+
+	g := NewGame(b, Human, Human)
+	g.State = Player1Turn
+
+	gameCh <- g
+
+	time.Sleep(50 * time.Millisecond)
+
+	// the page is served
+	res, err := http.Get(fmt.Sprintf("http://localhost:%d/", port))
+	if err != nil {
+		t.Fatalf("Request error: %v", err)
+	}
+
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.True(t, strings.HasPrefix(res.Header.Get("Content-Type"), "text/html"))
+	assert.Contains(t, string(body), "<title>Go Reversi</title>")
+
+	res, err = http.Get(fmt.Sprintf("http://localhost:%d/favicon.ico", port))
+	if err != nil {
+		t.Fatalf("Request error: %v", err)
+	}
+	res.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	// the page connects to the same url as websocket
+	conn, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("ws://localhost:%d/", port), nil)
+	if err != nil {
+		t.Fatalf("Connection error: %v", err)
+	}
+
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	var receivedGame Game
+	if err := conn.ReadJSON(&receivedGame); err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+
+	assert.Equal(t, Player1Turn.String(), receivedGame.State.String())
+
+	// the seat is taken, so another guest is turned away
+	_, res, err = websocket.DefaultDialer.Dial(fmt.Sprintf("ws://localhost:%d/", port), nil)
+	assert.Error(t, err)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+	}
+
+	conn.WriteJSON(GameCommand{Quit: true})
+
+	select {
+	case quit := <-quitCh:
+		assert.Equal(t, true, quit)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Quit is not received")
+	}
+
+	hostConn.Close()
+}
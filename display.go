@@ -1,122 +1,411 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/pkg/term"
+	"golang.org/x/sys/unix"
 )
 
-const (
+// strings to draw the board, set by applyTheme
+var (
 	BlackString     = "○"
 	WhiteString     = "●"
 	NothingString   = "_"
 	LeftWallString  = "|"
 	RightWallString = "|"
 	CursorString    = "*"
-	Spacer          = "    "
+	HoleString      = "▒"
 )
 
+const Spacer = "    "
+
+const DEFAULT_THEME = "classic"
+
+// Theme is the set of strings to draw the board
+type Theme struct {
+	Black   string
+	White   string
+	Nothing string
+	Cursor  string
+	Hole    string
+}
+
+var themes = map[string]Theme{
+	"classic":  {"○", "●", "_", "*", "▒"},
+	"ascii":    {"x", "o", ".", "*", "#"},
+	"inverted": {"●", "○", "_", "*", "▒"},
+}
+
+func applyTheme(name string) error {
+	theme, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("Unknown theme %q", name)
+	}
+
+	BlackString = theme.Black
+	WhiteString = theme.White
+	NothingString = theme.Nothing
+	CursorString = theme.Cursor
+	HoleString = theme.Hole
+
+	return nil
+}
+
 type Renderer interface {
 	Render(g *Game, p Position)
+	Notify(message string)
 	Close()
 }
 
+const (
+	enterAltScreen  = "\033[?1049h\033[?25l"
+	leaveAltScreen  = "\033[?25h\033[?1049l"
+	messageTooSmall = "Terminal too small (%dx%d). Enlarge it to at least %dx%d."
+)
+
 type Display struct {
-	tm *term.Term
+	in     io.Reader
+	out    io.Writer
+	width  int
+	height int
+	mu     *sync.Mutex
+
+	// only for the local terminal
+	tm    *term.Term
+	sigCh chan os.Signal
+
+	// the last rendered state to redraw on resize
+	g      *Game
+	p      Position
+	notice string
+
+	// the evaluation bar beside the board, if enabled
+	evalBar    bool
+	eval       *Evaluation // the last finished evaluation, which may be of an older position
+	evalKey    string      // the position being evaluated
+	evalCancel context.CancelFunc
 }
 
-func NewDisplay() Display {
+// NewDisplay opens the local terminal in raw mode
+func NewDisplay() *Display {
 	tm, _ := term.Open("/dev/tty")
 	err := term.RawMode(tm)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	d := Display{tm}
+	width, height := terminalSize()
+
+	d := NewDisplayFrom(tm, width, height)
+	d.out = os.Stdout
+	d.tm = tm
+	d.sigCh = make(chan os.Signal, 1)
+
+	// redraw when the terminal is resized
+	signal.Notify(d.sigCh, syscall.SIGWINCH)
+	go func() {
+		for range d.sigCh {
+			d.Resize(terminalSize())
+		}
+	}()
+
+	return d
+}
+
+// NewDisplayFrom draws on any terminal connected by rw, e.g., an SSH channel with a PTY
+func NewDisplayFrom(rw io.ReadWriter, width, height int) *Display {
+	d := &Display{
+		in:     rw,
+		out:    rw,
+		width:  width,
+		height: height,
+		mu:     &sync.Mutex{},
+	}
+
+	fmt.Fprint(d.out, enterAltScreen)
 
 	return d
 }
 
-func (d *Display) Read(out chan<- string) {
+// Read reads one key and sends it to out
+func (d *Display) Read(out chan<- string) error {
 	readBytes := make([]byte, 1)
-	_, err := d.tm.Read(readBytes)
+	_, err := d.in.Read(readBytes)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
-	var char string
-	if readBytes[0] == 3 {
-		char = "c"
+	out <- string(readBytes[0])
 
-	} else {
-		char = string(readBytes[0])
-	}
+	return nil
+}
+
+// EnableEvalBar shows the evaluation bar, which the engine updates in the background after every move
+func (d *Display) EnableEvalBar() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-	out <- char
+	d.evalBar = true
 }
 
 func (d *Display) Close() {
-	d.tm.Restore()
-	d.tm.Close()
+	d.mu.Lock()
+	if d.evalCancel != nil {
+		d.evalCancel()
+	}
+	fmt.Fprint(d.out, leaveAltScreen)
+	d.mu.Unlock()
+
+	if d.tm != nil {
+		signal.Stop(d.sigCh)
+		close(d.sigCh)
+
+		d.tm.Restore()
+		d.tm.Close()
+	}
+}
+
+// Resize redraws the last state for the new terminal size
+func (d *Display) Resize(width, height int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.width, d.height = width, height
+
+	d.draw()
 }
 
 func (d *Display) Render(g *Game, p Position) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	copied := *g
+	d.g, d.p = &copied, p
+	d.notice = ""
+
+	if d.evalBar {
+		d.requestEvaluation(g.Board)
+	}
+
+	d.draw()
+}
+
+// Notify shows a message until the next game is rendered, e.g., while connecting to the server
+func (d *Display) Notify(message string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.notice = message
+
+	d.draw()
+}
+
+// draw clears the screen and draws the last state in the middle of the terminal
+func (d *Display) draw() {
+	var lines []string
+	if d.g == nil {
+		lines = strings.Split(d.notice, "\n")
+	} else {
+		var eval *Evaluation
+		if d.evalBar {
+			eval = d.eval
+			if eval == nil {
+				eval = &Evaluation{WinRate: 0.5}
+			}
+		}
 
-	// print message
-	print("")
-	print(fmt.Sprintf(" %s", g.GetInfo().Player1Info))
-	print(fmt.Sprintf(" %s", g.GetInfo().Player2Info))
-	print("")
+		lines = buildLines(d.g, d.p, eval, d.width)
+		if d.notice != "" {
+			lines = append(lines, "")
+			lines = append(lines, strings.Split(d.notice, "\n")...)
+		}
+	}
+
+	fmt.Fprint(d.out, frame(lines, d.width, d.height))
+}
 
+// buildLines returns the lines to show a game, with the evaluation bar if eval is not nil
+func buildLines(g *Game, p Position, eval *Evaluation, width int) []string {
 	b := g.Board
 	state := g.State
-	n := b.N
 
-	for y := 0; y < n; y++ {
+	// info and board on the left, panel on the right
+	boardLines := []string{
+		"",
+		fmt.Sprintf(" %s", g.GetInfo().Player1Info),
+		fmt.Sprintf(" %s", g.GetInfo().Player2Info),
+		"",
+	}
+
+	var bar []string
+	if eval != nil {
+		bar = evalBarCells(eval, b.H)
+	}
+
+	for y := 0; y < b.H; y++ {
 		rowStr := RightWallString
-		for x := 0; x < n; x++ {
+		for x := 0; x < b.W; x++ {
 			idx := b.Lines[LineId(y)]
 			s := idx.GetLocalState(x)
-			if y == p.Y && x == p.X { // on focus
+			if b.IsHole(Position{x, y}) {
+				rowStr += getHoleContent(y == p.Y && x == p.X)
+			} else if y == p.Y && x == p.X { // on focus
 				rowStr += getFocusedCellContent(s)
 			} else {
 				rowStr += getCellContent(s)
 			}
 		}
 		rowStr += LeftWallString
-		printWithSpacer(rowStr)
+		if bar != nil {
+			rowStr += " " + bar[y]
+		}
+		boardLines = append(boardLines, Spacer+rowStr)
 	}
 
-	print("")
+	if eval != nil {
+		boardLines = append(boardLines, "", fmt.Sprintf(" %s", evalLabel(eval, b)))
+	}
 
-	// print message
-	print(fmt.Sprintf("[Message] %s", g.Message))
+	lines := layoutWithPanel(boardLines, g.Moves, width)
+
+	lines = append(lines, "")
+
+	// print message, following lines are indented
+	for i, line := range strings.Split(g.Message, "\n") {
+		if i == 0 {
+			lines = append(lines, fmt.Sprintf("[Message] %s", line))
+		} else {
+			lines = append(lines, fmt.Sprintf("          %s", line))
+		}
+	}
 
 	// print key bindings
-	print("")
+	lines = append(lines, "")
+
+	quit := keyLabel(ActionQuit)
 
 	switch state {
 	case Quit, WaitingConnection:
-		print("[Keys] Quit: c")
+		lines = append(lines, fmt.Sprintf("[Keys] Quit: %s", quit))
 	case Finished:
-		print("[Keys] Play Again: r | Quit: c")
+		lines = append(lines, fmt.Sprintf("[Keys] Play Again: %s | Review: %s | Quit: %s", keyLabel(ActionReplay), keyLabel(ActionReview), quit))
+	case Replaying:
+		lines = append(lines, fmt.Sprintf(
+			"[Keys] Prev/Next: %s,%s | Start/End: %s,%s | Quit: %s",
+			keyLabel(ActionLeft),
+			keyLabel(ActionRight),
+			keyLabel(ActionUp),
+			keyLabel(ActionDown),
+			quit,
+		))
 	default:
-		print("[Keys] ←↓↑→: a,s,w,d | Place: <space> | Quit: c")
+		lines = append(lines, fmt.Sprintf(
+			"[Keys] ←↓↑→: %s,%s,%s,%s | Place: %s | Analyze: %s | Hint: %s | Quit: %s",
+			keyLabel(ActionLeft),
+			keyLabel(ActionDown),
+			keyLabel(ActionUp),
+			keyLabel(ActionRight),
+			keyLabel(ActionPlace),
+			keyLabel(ActionAnalyze),
+			keyLabel(ActionHint),
+			quit,
+		))
+	}
+
+	return lines
+}
+
+// frame returns the whole screen with the lines centred.
+// If the lines don't fit, it returns a notice instead
+func frame(lines []string, width, height int) string {
+	blockWidth := 0
+	for _, line := range lines {
+		blockWidth = max(blockWidth, visibleLen(line))
+	}
+
+	if blockWidth > width || len(lines) > height {
+		lines = []string{fmt.Sprintf(messageTooSmall, width, height, blockWidth, len(lines))}
+		blockWidth = visibleLen(lines[0])
+	}
+
+	left := strings.Repeat(" ", max((width-blockWidth)/2, 0))
+	top := max((height-len(lines))/2, 0)
+
+	var builder strings.Builder
+
+	// move the cursor to the top left
+	builder.WriteString("\033[H")
+
+	for i := 0; i < top; i++ {
+		builder.WriteString("\033[K\r\n")
+	}
+
+	for i, line := range lines {
+		builder.WriteString("\033[K")
+		builder.WriteString(left + line)
+		if i < len(lines)-1 {
+			builder.WriteString("\r\n")
+		}
 	}
 
-	// move curosr up
-	fmt.Printf("\033[%dA\r", n+8)
+	// clear what is left from the previous draw
+	builder.WriteString("\033[J")
+
+	return builder.String()
 }
 
-func printWithSpacer(s string) {
-	fmt.Printf("\r\033[K%s%s", Spacer, s)
-	fmt.Print("\n")
+// layoutWithPanel puts the panel next to the board, or below the board if the terminal is narrow
+func layoutWithPanel(boardLines []string, moves []Move, width int) []string {
+	boardWidth := 0
+	for _, line := range boardLines {
+		boardWidth = max(boardWidth, visibleLen(line))
+	}
+
+	if boardWidth+len(panelGap)+PanelWidth <= width {
+		panel := buildPanel(moves, PanelWidth, len(boardLines))
+
+		lines := make([]string, 0, max(len(boardLines), len(panel)))
+		for i := 0; i < max(len(boardLines), len(panel)); i++ {
+			left, right := "", ""
+			if i < len(boardLines) {
+				left = boardLines[i]
+			}
+			if i < len(panel) {
+				right = panel[i]
+			}
+			lines = append(lines, strings.TrimRight(padRight(left, boardWidth)+panelGap+right, " "))
+		}
+
+		return lines
+	}
+
+	// below the board, only a few lines of moves are shown
+	panel := buildPanel(moves, max(width-1, 1), 6)
+
+	lines := append([]string{}, boardLines...)
+	lines = append(lines, "")
+	lines = append(lines, panel...)
+
+	return lines
 }
 
-func print(s string) {
-	fmt.Printf("\r\033[K%s", s)
-	fmt.Print("\n")
+// terminalSize returns the width and height of the terminal. It falls back to 80x24
+func terminalSize() (int, int) {
+	ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 80, 24
+	}
+
+	return int(ws.Col), int(ws.Row)
 }
 
 func getFocusedCellContent(s State) string {
@@ -129,6 +418,13 @@ func getFocusedCellContent(s State) string {
 	}
 }
 
+func getHoleContent(focused bool) string {
+	if focused {
+		return fmt.Sprintf("|%s", HoleString)
+	}
+	return fmt.Sprintf(" %s", HoleString)
+}
+
 func getCellContent(s State) string {
 	if s == HasNothing {
 		return fmt.Sprintf(" %s", NothingString)
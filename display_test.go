@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisplayFrameCentresLines(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	got := frame([]string{"ab", "abcd"}, 10, 4)
+
+	assert.Equal(t, "\033[H\033[K\r\n\033[K   ab\r\n\033[K   abcd\033[J", got)
+}
+
+func TestDisplayFrameTooSmall(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	g := NewGame(NewBoard(8), Human, Human)
+
+	lines := buildLines(&g, Position{}, nil, 20)
+
+	got := frame(lines, 20, 10)
+
+	assert.True(t, strings.Contains(got, "Terminal too small (20x10)"))
+	assert.False(t, strings.Contains(got, BlackString))
+}
+
+func TestDisplayBuildLinesPanelPlacement(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	g := NewGame(NewBoard(4), Human, Human)
+
+	// the panel is next to the board
+	wide := buildLines(&g, Position{}, nil, 80)
+	assert.True(t, strings.HasSuffix(wide[0], "Moves"))
+
+	// the panel is below the board
+	narrow := buildLines(&g, Position{}, nil, 20)
+	assert.Equal(t, "Moves", narrow[4+4+1])
+}
+
+func TestDisplayMultiLineMessage(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	g := NewGame(NewBoard(4), Human, Human)
+	g.Message = "first\nsecond"
+
+	lines := buildLines(&g, Position{}, nil, 80)
+
+	assert.Contains(t, lines, "[Message] first")
+	assert.Contains(t, lines, "          second")
+
+	out := &safeBuffer{}
+	d := NewDisplayFrom(&struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader(""), out}, 80, 40)
+	d.Render(&g, Position{})
+	d.Notify("line 1\nline 2")
+
+	got := out.String()
+	assert.Regexp(t, `\033\[K +line 1\r\n\033\[K +line 2`, got)
+}
+
+func TestDisplayBuildLinesRectangle(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	g := NewGame(NewRectBoard(6, 4, Variant{}), Human, Human)
+
+	lines := buildLines(&g, Position{5, 3}, &Evaluation{WinRate: 0.5}, 20)
+
+	// 4 rows of 6 cells after the info, each with the bar
+	empty := strings.Repeat(getCellContent(HasNothing), 6)
+	assert.True(t, strings.HasPrefix(lines[4], Spacer+RightWallString+empty+LeftWallString+" "))
+	assert.True(t, strings.HasPrefix(lines[7], Spacer+RightWallString+empty[:len(empty)-2]+getFocusedCellContent(HasNothing)+LeftWallString+" "))
+	assert.Equal(t, "", lines[8])
+	assert.Contains(t, lines[9], "Eval:")
+}
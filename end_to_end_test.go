@@ -26,7 +26,7 @@ func TestEndToEnd(t *testing.T) {
 	}
 
 	// Start the host
-	go hostStarter.Start(3, DEFAULT_PORT)
+	go hostStarter.Start(NewBoard(3), DEFAULT_PORT)
 
 	time.Sleep(500 * time.Millisecond)
 
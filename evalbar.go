@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// the evaluation bar searches to this depth or time, whichever comes first
+	evalBarDepth = 8
+	evalBarTime  = time.Second
+
+	// positions with this number of empty cells or fewer are solved to the end, if it finishes in time
+	evalBarSolveEmpties = 10
+	evalBarSolveTime    = 3 * time.Second
+
+	// the score that is about 73% to win. The scores are not discs, so it's only a rough guess
+	evalBarScale = 40.0
+)
+
+// Evaluation is the estimated result of a position shown by the evaluation bar
+type Evaluation struct {
+	Key     string  // positionKey of the evaluated board
+	Score   int     // for black. The disc difference at the end if Exact, reversed in anti-reversi so that black wins when it is positive
+	WinRate float64 // the chance that black wins, from 0 to 1. A draw is a half
+	Exact   bool    // solved to the end of the game
+	Depth   int
+}
+
+// evaluateBoard searches the board and estimates the result for black.
+// It stops with the error of the context when it's cancelled
+func evaluateBoard(ctx context.Context, b *Board) (Evaluation, error) {
+	e := Evaluation{Key: positionKey(b)}
+
+	b = b.CopyBoard()
+
+	if !b.HasLegalMove(b.Turn) {
+		if !b.HasLegalMove(!b.Turn) {
+			// game finished
+			e.Score, e.Exact = b.Result(), true
+			e.WinRate = winRate(e.Score, true)
+			return e, nil
+		}
+
+		b.SwitchTurn()
+	}
+
+	// the scores of the search are for the player to move
+	sign := 1
+	if b.Turn == White {
+		sign = -1
+	}
+
+	ap := newAiPlayerFor(b)
+	ap.Colour = b.Turn
+	ap.ctx = ctx
+
+	empties := b.CountEmptyCells()
+
+	if empties <= evalBarSolveEmpties {
+		score, _, err := ap.search(b, empties, true, time.Now().Add(evalBarSolveTime))
+		if err == nil {
+			e.Score, e.Exact, e.Depth = sign*score, true, empties
+			e.WinRate = winRate(e.Score, true)
+			return e, nil
+		}
+		if ctx.Err() != nil {
+			return e, ctx.Err()
+		}
+	}
+
+	results := ap.Analyze(b, evalBarDepth, evalBarTime)
+	if ctx.Err() != nil {
+		return e, ctx.Err()
+	}
+
+	best := results[0]
+	e.Score, e.Depth = sign*best.Score, best.Depth
+	e.Exact = best.Depth > empties
+	e.WinRate = winRate(e.Score, e.Exact)
+
+	return e, nil
+}
+
+// winRate converts the score for black to the chance that black wins
+func winRate(score int, exact bool) float64 {
+	if exact {
+		switch {
+		case score > 0:
+			return 1
+		case score < 0:
+			return 0
+		}
+		return 0.5
+	}
+
+	return 1 / (1 + math.Exp(-float64(score)/evalBarScale))
+}
+
+// evalBarCells returns the cells of the bar from the top, filled with black from the bottom by the chance that black wins
+func evalBarCells(e *Evaluation, n int) []string {
+	blackCells := int(math.Round(e.WinRate * float64(n)))
+
+	cells := make([]string, n)
+	for y := range cells {
+		if y >= n-blackCells {
+			cells[y] = BlackString
+		} else {
+			cells[y] = WhiteString
+		}
+	}
+
+	return cells
+}
+
+// evalLabel describes the evaluation under the board. The bar of an older position is kept until the new one is ready
+func evalLabel(e *Evaluation, b *Board) string {
+	if e.Key != positionKey(b) {
+		return "Eval: ..."
+	}
+
+	if e.Exact {
+		if e.Score == 0 {
+			return "Eval: Draw (solved)"
+		}
+
+		winner := Black
+		if e.Score < 0 {
+			winner = White
+		}
+		return fmt.Sprintf("Eval: %s wins by %d (solved)", colourName(winner), abs(e.Score))
+	}
+
+	return fmt.Sprintf("Eval: Black %.0f%%, White %.0f%% (depth %d)", e.WinRate*100, (1-e.WinRate)*100, e.Depth)
+}
+
+// requestEvaluation evaluates the board in the background and redraws when it's done.
+// The evaluation of the previous board is cancelled. It's called with d.mu locked
+func (d *Display) requestEvaluation(b *Board) {
+	key := positionKey(b)
+	if key == d.evalKey {
+		return
+	}
+
+	if d.evalCancel != nil {
+		d.evalCancel()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.evalKey, d.evalCancel = key, cancel
+
+	b = b.CopyBoard()
+
+	go func() {
+		e, err := evaluateBoard(ctx, b)
+		if err != nil {
+			return
+		}
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		// cancelled while waiting for the lock
+		if ctx.Err() != nil {
+			return
+		}
+
+		d.eval = &e
+		d.draw()
+	}()
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateBoardSolved(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(5)
+	b.FromStringCells(
+		[][]string{
+			{"b", "b", "b", "b", "n"},
+			{"w", "b", "b", "w", "w"},
+			{"w", "b", "w", "w", "n"},
+			{"w", "w", "w", "w", "b"},
+			{"w", "b", "b", "n", "n"},
+		},
+	)
+
+	// black wins with e5
+	e, err := evaluateBoard(context.Background(), b)
+	assert.NoError(t, err)
+	assert.True(t, e.Exact)
+	assert.Greater(t, e.Score, 0)
+	assert.Equal(t, 1.0, e.WinRate)
+	assert.Equal(t, positionKey(b), e.Key)
+	assert.Equal(t, "Eval: Black wins by 5 (solved)", evalLabel(&e, b))
+}
+
+func TestEvaluateBoardFinished(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(3)
+	b.FromStringCells(
+		[][]string{
+			{"w", "w", "w"},
+			{"w", "b", "w"},
+			{"w", "w", "w"},
+		},
+	)
+
+	e, err := evaluateBoard(context.Background(), b)
+	assert.NoError(t, err)
+	assert.Equal(t, Evaluation{Key: positionKey(b), Score: -7, WinRate: 0, Exact: true}, e)
+}
+
+func TestEvaluateBoardEstimated(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(8)
+
+	e, err := evaluateBoard(context.Background(), b)
+	assert.NoError(t, err)
+	assert.False(t, e.Exact)
+	assert.Greater(t, e.Depth, 0)
+	assert.Greater(t, e.WinRate, 0.0)
+	assert.Less(t, e.WinRate, 1.0)
+
+	// a cancelled evaluation has no result
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = evaluateBoard(ctx, b)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestWinRate(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	assert.Equal(t, 0.5, winRate(0, false))
+	assert.InDelta(t, 0.73, winRate(evalBarScale, false), 0.01)
+	assert.InDelta(t, 0.27, winRate(-evalBarScale, false), 0.01)
+
+	assert.Equal(t, 1.0, winRate(2, true))
+	assert.Equal(t, 0.0, winRate(-2, true))
+	assert.Equal(t, 0.5, winRate(0, true))
+}
+
+func TestDisplayBuildLinesEvalBar(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	g := NewGame(NewBoard(4), Human, Human)
+	e := &Evaluation{Key: positionKey(g.Board), WinRate: 0.75, Depth: 5}
+
+	lines := buildLines(&g, Position{}, e, 20)
+
+	// 3 of 4 cells are black from the bottom
+	assert.True(t, strings.HasSuffix(lines[4], " "+WhiteString))
+	for _, line := range lines[5:8] {
+		assert.True(t, strings.HasSuffix(line, " "+BlackString))
+	}
+	assert.Equal(t, " Eval: Black 75%, White 25% (depth 5)", lines[9])
+
+	// the bar of an older position waits for the new one
+	e.Key = ""
+	lines = buildLines(&g, Position{}, e, 20)
+	assert.Equal(t, " Eval: ...", lines[9])
+}
+
+func TestDisplayEvalBar(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	out := &safeBuffer{}
+	d := NewDisplayFrom(&struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader(""), out}, 80, 40)
+	d.EnableEvalBar()
+	defer d.Close()
+
+	g := NewGame(NewBoard(4), Human, Human)
+	d.Render(&g, Position{})
+
+	// redrawn when the evaluation in the background finishes
+	assert.Contains(t, out.String(), "Eval: ...")
+	assert.Eventually(t, func() bool {
+		return strings.Contains(out.String(), "Eval: Black ")
+	}, 10*time.Second, 10*time.Millisecond)
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log/slog"
+	"slices"
 )
 
 type GameState int
@@ -14,6 +15,7 @@ const (
 	Player2Turn
 	Finished
 	Quit
+	Replaying // showing a saved game, not played
 )
 
 const (
@@ -24,6 +26,10 @@ const (
 	messageWin       string = "Black %d, White %d, %s won ✨"
 	messageDraw      string = "Black %d, White %d, Draw 👏"
 	messageQuit      string = "%s left the game 🚪"
+	messageUndone    string = "↩️  Undone. %s  %s's turn"
+	messageNoUndo    string = "There is no move to undo."
+	messageVariant   string = "%s (%s)"
+	messageSetup     string = "%s\nPlace the first four discs on the centre."
 )
 
 func (gs GameState) String() string {
@@ -40,6 +46,8 @@ func (gs GameState) String() string {
 		return "Finished"
 	case Quit:
 		return "Quit"
+	case Replaying:
+		return "Replaying"
 	default:
 		return "Not Defined"
 	}
@@ -52,6 +60,24 @@ type Game struct {
 	Player2 Player
 	Info    GameInfo
 	Message string
+	Moves   []Move // moves played in this game, including passes
+
+	history []snapshot // boards before each placement, used for undo
+}
+
+// snapshot is the board before a placement and the number of moves played at that time
+type snapshot struct {
+	board *Board
+	moveN int
+}
+
+// Move is one entry of the move history
+type Move struct {
+	Colour   Turn
+	Position Position
+	Pass     bool
+	Black    int // number of black discs after the move
+	White    int // number of white discs after the move
 }
 
 func NewGame(b *Board, type1, type2 PlayerType) Game {
@@ -67,10 +93,12 @@ func NewGame(b *Board, type1, type2 PlayerType) Game {
 	return Game{
 		b,
 		Initialized,
-		Player{name1, false, type1, Black},
-		Player{name2, false, type2, White},
+		Player{name1, false, type1, Black, 0},
+		Player{name2, false, type2, White, 0},
 		GameInfo{},
 		"",
+		nil,
+		nil,
 	}
 }
 
@@ -84,33 +112,46 @@ func (g *Game) Start() (chan GameCommand, chan GameCommand, chan Game, chan Game
 	player1Quit := make(chan bool)
 	player2Quit := make(chan bool)
 
+	// the game loop doesn't wait for the players to receive the game,
+	// and each player receives the games in the order they are broadcast
+	player1Queue := queueGames(player1Game)
+	player2Queue := queueGames(player2Game)
+
 	// broadcast game status
 	broadcast := func() {
-		player1Game <- *g
-		player2Game <- *g
+		player1Queue <- *g
+		player2Queue <- *g
 	}
 
-	// listen to quit chans
-	go func() {
-		quit := false
-		quittingPlayer := g.Player1.Name
-
-		select {
-		case quit = <-player1Quit:
-			logger.Debug("Quit received", slog.String("id", Player1Id.String()))
-		case quit = <-player2Quit:
-			quittingPlayer = g.Player2.Name
-			logger.Debug("Quit received", slog.String("id", Player2Id.String()))
-		}
-
-		if quit {
-			g.State = Quit
-			g.Message = fmt.Sprintf(messageQuit, quittingPlayer)
-
-			broadcast()
-			logger.Debug("Quit is sent")
+	// receive waits for a command from the players whose channels are given, or for a player to quit.
+	// A nil channel never receives, and a closed quit channel stops being listened to
+	quit1, quit2 := player1Quit, player2Quit
+	receive := func(cmd1, cmd2 chan GameCommand) (GameCommand, PlayerId, bool) {
+		for {
+			select {
+			case cmd := <-cmd1:
+				return cmd, Player1Id, true
+			case cmd := <-cmd2:
+				return cmd, Player2Id, true
+			case quit, ok := <-quit1:
+				if !ok {
+					quit1 = nil
+				} else if quit {
+					logger.Debug("Quit received", slog.String("id", Player1Id.String()))
+					g.quit(g.Player1.Name)
+					return GameCommand{}, Player1Id, false
+				}
+			case quit, ok := <-quit2:
+				if !ok {
+					quit2 = nil
+				} else if quit {
+					logger.Debug("Quit received", slog.String("id", Player2Id.String()))
+					g.quit(g.Player2.Name)
+					return GameCommand{}, Player2Id, false
+				}
+			}
 		}
-	}()
+	}
 
 	go func() {
 	gameLoop:
@@ -123,49 +164,61 @@ func (g *Game) Start() (chan GameCommand, chan GameCommand, chan Game, chan Game
 
 			case WaitingConnection:
 				// make sure both clients are connected
-				select {
-				case cmd := <-player1Cmd:
-					if cmd.CommandType == CommandConnectionCheck {
-						g.Player1.Ready = true
-					}
-				case cmd := <-player2Cmd:
-					if cmd.CommandType == CommandConnectionCheck {
-						g.Player2.Ready = true
+				cmd, id, ok := receive(player1Cmd, player2Cmd)
+				if !ok {
+					break
+				}
+
+				if cmd.CommandType == CommandConnectionCheck {
+					if id == Player1Id {
+						g.Player1.ready(cmd.Name)
+					} else {
+						g.Player2.ready(cmd.Name)
 					}
 				}
 
 				if g.Player1.Ready && g.Player2.Ready {
-					g.Message = messageGameStart
+					g.Message = g.startMessage()
 					g.updateTurnFromBoard()
 				}
 
 			case Player1Turn, Player2Turn:
-				// waiting for players' input
-				var cmd GameCommand
+				// waiting for the input of the player in turn
+				var cmd1, cmd2 chan GameCommand
 				if g.State == Player1Turn {
-					cmd = <-player1Cmd
+					cmd1 = player1Cmd
 				} else {
-					cmd = <-player2Cmd
+					cmd2 = player2Cmd
+				}
+
+				cmd, id, ok := receive(cmd1, cmd2)
+				if !ok {
+					break
 				}
 
 				switch cmd.CommandType {
 				// place
 				case CommandPlace:
 					g.place(cmd.Position)
+				case CommandUndo:
+					g.undo(id)
+				case CommandHint:
+					g.hint(id, cmd.Position)
 				}
 
 			case Finished:
 				// wait for input
-				var cmd GameCommand
-				select {
-				case cmd = <-player1Cmd:
-				case cmd = <-player2Cmd:
+				cmd, id, ok := receive(player1Cmd, player2Cmd)
+				if !ok {
+					break
 				}
 
 				switch cmd.CommandType {
 				case CommandReplay:
 					g.replay()
 					g.updateTurnFromBoard()
+				case CommandUndo:
+					g.undo(id)
 				}
 
 			case Quit:
@@ -173,13 +226,50 @@ func (g *Game) Start() (chan GameCommand, chan GameCommand, chan Game, chan Game
 			}
 
 			logger.Debug("Broadcast state", slog.String("state", g.State.String()))
-			go broadcast()
+			broadcast()
 		}
+
+		close(player1Queue)
+		close(player2Queue)
 	}()
 
 	return player1Cmd, player2Cmd, player1Game, player2Game, player1Quit, player2Quit
 }
 
+// queueGames returns a channel that never blocks, and sends its games to out in order.
+// Close the channel when the game ends, then it stops after sending the games left
+func queueGames(out chan<- Game) chan<- Game {
+	in := make(chan Game)
+
+	go func() {
+		queue := make([]Game, 0)
+		receive := in
+
+		for receive != nil || len(queue) > 0 {
+			// nil channel blocks, so nothing is sent while the queue is empty
+			var send chan<- Game
+			var next Game
+			if len(queue) > 0 {
+				send = out
+				next = queue[0]
+			}
+
+			select {
+			case g, ok := <-receive:
+				if !ok {
+					receive = nil
+					continue
+				}
+				queue = append(queue, g)
+			case send <- next:
+				queue = queue[1:]
+			}
+		}
+	}()
+
+	return in
+}
+
 func (g *Game) place(p Position) {
 	b, err := g.Board.Place(p)
 
@@ -187,8 +277,11 @@ func (g *Game) place(p Position) {
 		g.Message = fmt.Sprintf("%s", err)
 		return
 	}
+	g.history = append(g.history, snapshot{g.Board, len(g.Moves)})
 	g.Board = b
 
+	g.recordMove(!b.Turn, p, false)
+
 	// deal with pass
 	passedCount := 0
 	for !b.HasLegalMove(b.Turn) && passedCount <= 2 {
@@ -206,12 +299,73 @@ func (g *Game) place(p Position) {
 
 	// show skip message
 	if passedCount > 0 {
+		g.recordMove(!b.Turn, Position{}, true)
 		skipped := g.GetAnotherPlayer()
 		g.Message = fmt.Sprintf(messageSkipped, skipped.Colour, skipped.Name)
 	} else {
 		playing := g.GetCurrentPlayer()
 		g.Message = fmt.Sprintf(messageTurn, playing.Colour, playing.Name)
 	}
+
+	if b.InSetup() {
+		g.Message = fmt.Sprintf(messageSetup, g.Message)
+	}
+}
+
+// startMessage tells the variant and how to start, if it's not the standard game
+func (g *Game) startMessage() string {
+	m := messageGameStart
+	if v := g.Board.Variant.String(); v != "" {
+		m = fmt.Sprintf(messageVariant, m, v)
+	}
+
+	if g.Board.InSetup() {
+		m = fmt.Sprintf(messageSetup, m)
+	}
+
+	return m
+}
+
+// undo takes back moves until it's the player's turn again
+func (g *Game) undo(id PlayerId) {
+	colour := g.GetPlayer(id).Colour
+
+	i := len(g.history) - 1
+	for i >= 0 && g.history[i].board.Turn != colour {
+		i--
+	}
+
+	if i < 0 {
+		g.Message = messageNoUndo
+		return
+	}
+
+	g.Board = g.history[i].board
+	// clip so that appending doesn't overwrite moves of games already broadcast
+	g.Moves = slices.Clip(g.Moves[:g.history[i].moveN])
+	g.history = g.history[:i]
+
+	g.updateTurnFromBoard()
+
+	playing := g.GetCurrentPlayer()
+	g.Message = fmt.Sprintf(messageUndone, playing.Colour, playing.Name)
+}
+
+// hint counts the hint the player asked for, and tells why the move is good
+func (g *Game) hint(id PlayerId, p Position) {
+	if id == Player1Id {
+		g.Player1.Hints++
+	} else {
+		g.Player2.Hints++
+	}
+
+	g.Message = fmt.Sprintf(messageHint, p, hintReason(g.Board, p))
+}
+
+func (g *Game) recordMove(colour Turn, p Position, pass bool) {
+	totalB, totalW := g.Board.Count()
+
+	g.Moves = append(g.Moves, Move{colour, p, pass, totalB, totalW})
 }
 
 func (g *Game) updateTurnFromBoard() {
@@ -222,10 +376,20 @@ func (g *Game) updateTurnFromBoard() {
 	}
 }
 
+// quit ends the game because the named player left
+func (g *Game) quit(name string) {
+	g.State = Quit
+	g.Message = fmt.Sprintf(messageQuit, name)
+}
+
 func (g *Game) replay() {
 	// swap player colour
 	g.Player1.Colour, g.Player2.Colour = g.Player2.Colour, g.Player1.Colour
 
+	g.Moves = nil
+	g.history = nil
+	g.Player1.Hints, g.Player2.Hints = 0, 0
+
 	g.Board.Replay()
 }
 
@@ -235,8 +399,10 @@ func (g *Game) finish() {
 	g.State = Finished
 }
 
+// generateResultMessage tells the winner by the rule of the variant
 func (g *Game) generateResultMessage() string {
 	totalB, totalW := g.Board.Count()
+	result := g.Board.Result()
 
 	var playerB, playerW Player
 	if g.Player1.Colour == Black {
@@ -249,9 +415,9 @@ func (g *Game) generateResultMessage() string {
 
 	var m string
 
-	if totalB > totalW {
+	if result > 0 {
 		m = fmt.Sprintf(messageWin, totalB, totalW, playerB.Name)
-	} else if totalB < totalW {
+	} else if result < 0 {
 		m = fmt.Sprintf(messageWin, totalB, totalW, playerW.Name)
 	} else {
 		m = fmt.Sprintf(messageDraw, totalB, totalW)
@@ -301,6 +467,15 @@ type Player struct {
 	Ready  bool
 	Type   PlayerType
 	Colour Turn
+	Hints  int // hints asked for in this game
+}
+
+// ready marks the player as connected, and uses the name if the client sent one
+func (p *Player) ready(name string) {
+	p.Ready = true
+	if name != "" {
+		p.Name = name
+	}
 }
 
 type PlayerType int
@@ -355,6 +530,14 @@ func (g *Game) GetInfo() *GameInfo {
 	p1 := fmt.Sprintf("%s %s", p1Name, p1Colour)
 	p2 := fmt.Sprintf("%s %s", p2Name, p2Colour)
 
+	if g.Player1.Hints > 0 {
+		p1 += fmt.Sprintf(" 💡%d", g.Player1.Hints)
+	}
+
+	if g.Player2.Hints > 0 {
+		p2 += fmt.Sprintf(" 💡%d", g.Player2.Hints)
+	}
+
 	if g.State == Player1Turn {
 		p1 += " *"
 	}
@@ -390,6 +573,8 @@ const (
 	CommandPlace CommandType = iota
 	CommandConnectionCheck
 	CommandReplay
+	CommandUndo
+	CommandHint
 )
 
 func (c CommandType) String() string {
@@ -400,6 +585,10 @@ func (c CommandType) String() string {
 		return "CommandConnectionCheck"
 	case CommandReplay:
 		return "CommandReplay"
+	case CommandUndo:
+		return "CommandUndo"
+	case CommandHint:
+		return "CommandHint"
 	default:
 		return "Unknown"
 	}
@@ -409,4 +598,5 @@ type GameCommand struct {
 	CommandType CommandType
 	Position    Position
 	Quit        bool
+	Name        string // player name sent with CommandConnectionCheck
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"log/slog"
+	"runtime"
 	"testing"
 	"time"
 
@@ -37,6 +38,22 @@ func TestGameStart(t *testing.T) {
 	assert.Equal(t, Player1Turn, g.State)
 }
 
+func TestGamePlayerName(t *testing.T) {
+	g, player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, _, _ := gameTestInit(make([][]string, 0))
+
+	mockSync(player1GameCh, player2GameCh)
+
+	player1CmdCh <- GameCommand{CommandType: CommandConnectionCheck}
+	mockSync(player1GameCh, player2GameCh)
+
+	player2CmdCh <- GameCommand{CommandType: CommandConnectionCheck, Name: "Alice"}
+	mockSync(player1GameCh, player2GameCh)
+
+	// the name is kept if the client didn't send one
+	assert.Equal(t, "Player 1", g.Player1.Name)
+	assert.Equal(t, "Alice", g.Player2.Name)
+}
+
 func TestGamePass(t *testing.T) {
 	g, player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, _, _ := gameTestInit(
 		[][]string{
@@ -171,6 +188,43 @@ func TestGameQuit(t *testing.T) {
 	assert.Equal(t, Quit, g.State)
 }
 
+func TestGameQuitAfterFinished(t *testing.T) {
+	_, player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, player1QuitCh, _ := gameTestInit(
+		[][]string{
+			{"n", "w", "w"},
+			{"w", "w", "w"},
+			{"b", "w", "w"},
+		},
+	)
+
+	mockSync(player1GameCh, player2GameCh)
+	cmd := GameCommand{CommandType: CommandConnectionCheck}
+	player1CmdCh <- cmd
+	mockSync(player1GameCh, player2GameCh)
+	player2CmdCh <- cmd
+	mockSync(player1GameCh, player2GameCh)
+
+	// the last move finishes the game
+	player1CmdCh <- GameCommand{CommandType: CommandPlace, Position: Position{0, 0}}
+	g1 := <-player1GameCh
+	<-player2GameCh
+	assert.Equal(t, Finished, g1.State)
+
+	player1QuitCh <- true
+
+	g1 = <-player1GameCh
+	g2 := <-player2GameCh
+	assert.Equal(t, Quit, g1.State)
+	assert.Equal(t, Quit, g2.State)
+
+	// the game loop has stopped
+	select {
+	case player1CmdCh <- GameCommand{CommandType: CommandReplay}:
+		t.Fatal("the game loop still receives commands")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
 func gameTestInit(initBoard [][]string) (*Game, chan GameCommand, chan GameCommand, chan Game, chan Game, chan bool, chan bool) {
 	logger = NewLogger(slog.LevelInfo)
 
@@ -191,3 +245,136 @@ func mockSync(player1GameCh, player2GameCh chan Game) {
 	_ = <-player1GameCh
 	_ = <-player2GameCh
 }
+
+func TestGameRecordsMoves(t *testing.T) {
+	g, player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, _, _ := gameTestInit(
+		[][]string{
+			{"n", "n", "n"},
+			{"w", "b", "b"},
+			{"b", "w", "w"},
+		},
+	)
+
+	// connection check
+	mockSync(player1GameCh, player2GameCh)
+	cmd := GameCommand{CommandType: CommandConnectionCheck}
+	player1CmdCh <- cmd
+	mockSync(player1GameCh, player2GameCh)
+
+	player2CmdCh <- cmd
+	mockSync(player1GameCh, player2GameCh)
+
+	player1CmdCh <- GameCommand{CommandType: CommandPlace, Position: Position{0, 0}}
+	mockSync(player1GameCh, player2GameCh)
+
+	// player 1 is skipped after this
+	player2CmdCh <- GameCommand{CommandType: CommandPlace, Position: Position{2, 0}}
+	mockSync(player1GameCh, player2GameCh)
+
+	assert.Equal(t, []Move{
+		{Black, Position{0, 0}, false, 5, 2},
+		{White, Position{2, 0}, false, 4, 4},
+		{Black, Position{}, true, 4, 4},
+	}, g.Moves)
+}
+
+func TestGameUndo(t *testing.T) {
+	g, player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, _, _ := gameTestInit(make([][]string, 0))
+
+	// connection check
+	mockSync(player1GameCh, player2GameCh)
+	cmd := GameCommand{CommandType: CommandConnectionCheck}
+	player1CmdCh <- cmd
+	mockSync(player1GameCh, player2GameCh)
+
+	player2CmdCh <- cmd
+	mockSync(player1GameCh, player2GameCh)
+
+	// nothing to undo
+	player1CmdCh <- GameCommand{CommandType: CommandUndo}
+	mockSync(player1GameCh, player2GameCh)
+	assert.Equal(t, messageNoUndo, g.Message)
+
+	player1CmdCh <- GameCommand{CommandType: CommandPlace, Position: Position{0, 2}}
+	mockSync(player1GameCh, player2GameCh)
+
+	player2CmdCh <- GameCommand{CommandType: CommandPlace, Position: Position{1, 2}}
+	mockSync(player1GameCh, player2GameCh)
+
+	// player 1 takes back both moves
+	player1CmdCh <- GameCommand{CommandType: CommandUndo}
+	mockSync(player1GameCh, player2GameCh)
+
+	assert.Equal(t, Player1Turn, g.State)
+	assert.Equal(t, 0, len(g.Moves))
+	assert.Equal(t, HasNothing, g.Board.GetCellState(Position{0, 2}))
+	assert.Equal(t, HasNothing, g.Board.GetCellState(Position{1, 2}))
+}
+
+func TestGameHint(t *testing.T) {
+	g, player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, _, _ := gameTestInit(make([][]string, 0))
+
+	// connection check
+	mockSync(player1GameCh, player2GameCh)
+	cmd := GameCommand{CommandType: CommandConnectionCheck}
+	player1CmdCh <- cmd
+	mockSync(player1GameCh, player2GameCh)
+
+	player2CmdCh <- cmd
+	mockSync(player1GameCh, player2GameCh)
+
+	player1CmdCh <- GameCommand{CommandType: CommandHint, Position: Position{0, 2}}
+	mockSync(player1GameCh, player2GameCh)
+
+	// the hint doesn't place
+	assert.Equal(t, Player1Turn, g.State)
+	assert.Equal(t, 0, len(g.Moves))
+	assert.Equal(t, 1, g.Player1.Hints)
+	assert.Equal(t, 0, g.Player2.Hints)
+	assert.Contains(t, g.Message, "Hint: a3 ")
+	assert.Contains(t, g.GetInfo().Player1Info, "💡1")
+	assert.NotContains(t, g.GetInfo().Player2Info, "💡")
+
+	r := g.Record()
+	assert.Equal(t, 1, r.BlackHints)
+	assert.Equal(t, 0, r.WhiteHints)
+
+	// a new game starts without hints
+	g.replay()
+	assert.Equal(t, 0, g.Player1.Hints)
+}
+
+func TestQueueGamesKeepsOrder(t *testing.T) {
+	out := make(chan Game)
+	queue := queueGames(out)
+
+	// sending doesn't wait for the receiver
+	for i := 0; i < 5; i++ {
+		queue <- Game{Message: fmt.Sprint(i)}
+	}
+
+	for i := 0; i < 5; i++ {
+		g := <-out
+		assert.Equal(t, fmt.Sprint(i), g.Message)
+	}
+}
+
+func TestQueueGamesStopsWhenClosed(t *testing.T) {
+	before := runtime.NumGoroutine()
+	out := make(chan Game)
+	queue := queueGames(out)
+
+	queue <- Game{Message: "0"}
+	queue <- Game{Message: "1"}
+	close(queue)
+
+	// the games left are still sent
+	assert.Equal(t, "0", (<-out).Message)
+	assert.Equal(t, "1", (<-out).Message)
+
+	// the queue goroutine returns
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// the search for a hint stops at this depth or time, whichever comes first
+	hintDepth = 6
+	hintTime  = time.Second
+
+	messageThinking = "💡  Thinking..."
+	messageHint     = "💡  Hint: %s %s"
+)
+
+// findHint searches the board for the player to move, and returns the best move.
+// It shows that it's thinking, as it takes up to hintTime
+func findHint(b *Board, d Renderer) (Position, bool) {
+	d.Notify(messageThinking)
+
+	results := newAiPlayerFor(b).Analyze(b, hintDepth, hintTime)
+	if len(results) == 0 {
+		return Position{}, false
+	}
+
+	return results[0].Position, true
+}
+
+// hintReason tells why the move is good in a few words
+func hintReason(b *Board, p Position) string {
+	if b.Variant.Rule == RuleAnti {
+		return antiHintReason(b, p)
+	}
+
+	if cellHeuristic(b, p) == 3 {
+		return "takes a corner"
+	}
+
+	placed, err := b.Place(p)
+	if err != nil {
+		return "is not a legal move"
+	}
+
+	mobility := placed.CountLegalMoves(placed.Turn)
+	if mobility == 0 {
+		return "makes the opponent pass"
+	}
+
+	fewest := true
+	for _, other := range legalPositions(b) {
+		otherPlaced, _ := b.Place(other)
+		if otherPlaced.CountLegalMoves(otherPlaced.Turn) < mobility {
+			fewest = false
+		}
+	}
+
+	if fewest {
+		return fmt.Sprintf("minimizes opponent mobility (%d moves)", mobility)
+	}
+
+	if cellHeuristic(b, p) == 2 {
+		return "takes an edge"
+	}
+
+	return "is the best in the search"
+}
+
+// antiHintReason tells why the move is good when fewer discs win.
+// Corners and edges keep discs, and a pass makes the player move again, so they are no reasons
+func antiHintReason(b *Board, p Position) string {
+	placed, err := b.Place(p)
+	if err != nil {
+		return "is not a legal move"
+	}
+
+	flips := flipCount(b, placed)
+	for _, other := range legalPositions(b) {
+		otherPlaced, _ := b.Place(other)
+		if flipCount(b, otherPlaced) < flips {
+			return "is the best in the search"
+		}
+	}
+
+	return fmt.Sprintf("flips the fewest discs (%d)", flips)
+}
+
+// flipCount returns the number of discs the move from b to placed flipped
+func flipCount(b, placed *Board) int {
+	black, white := b.Count()
+	placedBlack, placedWhite := placed.Count()
+
+	if b.Turn == Black {
+		return white - placedWhite
+	}
+	return black - placedBlack
+}
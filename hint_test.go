@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHintReason(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(4)
+	b.FromStringCells(
+		[][]string{
+			{"n", "w", "b", "n"},
+			{"n", "w", "w", "n"},
+			{"n", "b", "w", "n"},
+			{"n", "n", "n", "n"},
+		},
+	)
+
+	assert.Equal(t, "takes a corner", hintReason(b, Position{0, 0}))
+	assert.Equal(t, "is not a legal move", hintReason(b, Position{1, 3}))
+
+	// every first move leaves the opponent 3 moves
+	b = NewBoard(8)
+	assert.Equal(t, "minimizes opponent mobility (3 moves)", hintReason(b, Position{4, 2}))
+
+	// c1 takes the last white disc
+	b = NewBoard(4)
+	b.FromStringCells(
+		[][]string{
+			{"b", "w", "n", "n"},
+			{"n", "n", "n", "n"},
+			{"n", "n", "n", "n"},
+			{"n", "n", "n", "n"},
+		},
+	)
+	assert.Equal(t, "makes the opponent pass", hintReason(b, Position{2, 0}))
+
+	// fewer discs win, so the corner is no reason
+	b = NewBoardWithVariant(4, Variant{Rule: RuleAnti})
+	b.FromStringCells(
+		[][]string{
+			{"n", "w", "b", "n"},
+			{"n", "w", "w", "n"},
+			{"n", "b", "w", "n"},
+			{"n", "n", "n", "n"},
+		},
+	)
+	assert.Equal(t, "flips the fewest discs (1)", hintReason(b, Position{0, 0}))
+	assert.Equal(t, "is the best in the search", hintReason(b, Position{2, 3}))
+	assert.Equal(t, "is not a legal move", hintReason(b, Position{1, 3}))
+}
+
+func TestFindHint(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(6)
+	d := &MockDisplay{}
+
+	p, ok := findHint(b, d)
+
+	assert.True(t, ok)
+	assert.True(t, b.IsLegal(p.X+p.Y*b.N, b.Turn))
+}
+
+func TestFindHintForGuest(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	// the guest's board is decoded from the game the host sent
+	g := NewGame(NewBoardWithVariant(6, Variant{Holes: []string{"a1", "f6"}}), Human, Human)
+
+	data, err := json.Marshal(g)
+	assert.NoError(t, err)
+
+	var received Game
+	assert.NoError(t, json.Unmarshal(data, &received))
+
+	b := received.Board
+	assert.True(t, b.IsHole(Position{0, 0}))
+
+	p, ok := findHint(b, &MockDisplay{})
+
+	assert.True(t, ok)
+	assert.True(t, b.IsLegal(p.X+p.Y*b.W, b.Turn))
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	// a hole in the holes file and in the board rows
+	HoleMark = "#"
+
+	// random holes are chosen again until the first player has a legal move
+	holesAttempts = 100
+)
+
+// holeMask returns the cells of the holes, nil if there is none. The holes must be valid
+func holeMask(w, h int, holes []string) []bool {
+	if len(holes) == 0 {
+		return nil
+	}
+
+	mask := make([]bool, w*h)
+	for _, hole := range holes {
+		p, _ := parseCoordinate(hole)
+		mask[p.X+p.Y*w] = true
+	}
+
+	return mask
+}
+
+// validateHoles checks that the holes are on the board, not on the centre, and not repeated
+func validateHoles(w, h int, holes []string) error {
+	centre := centreCells(w, h)
+	seen := make(map[string]bool)
+
+	for _, hole := range holes {
+		p, err := parseCoordinate(hole)
+		if err != nil {
+			return err
+		}
+
+		if p.X >= w || p.Y >= h {
+			return fmt.Errorf("Hole %s is out of the board", hole)
+		}
+
+		if slices.Contains(centre, p.X+p.Y*w) {
+			return fmt.Errorf("Hole %s is on the centre", hole)
+		}
+
+		if seen[p.String()] {
+			return fmt.Errorf("Hole %s is repeated", hole)
+		}
+		seen[p.String()] = true
+	}
+
+	return nil
+}
+
+// randomHoles chooses k holes that are symmetrical about the centre of the board, so that neither player is favoured.
+// They are chosen again if the first player can't place at the start
+func randomHoles(w, h int, k int, v Variant, rng *rand.Rand) ([]string, error) {
+	centre := centreCells(w, h)
+
+	// each pair is a cell and its 180 degree rotation
+	pairs := make([][2]int, 0)
+	for cell := 0; cell < w*h-1-cell; cell++ {
+		rotated := w*h - 1 - cell
+		if !slices.Contains(centre, cell) && !slices.Contains(centre, rotated) {
+			pairs = append(pairs, [2]int{cell, rotated})
+		}
+	}
+
+	if k%2 != 0 || k < 0 || k > len(pairs)*2 {
+		return nil, fmt.Errorf("-holes must be an even number up to %d on %dx%d: %d", len(pairs)*2, w, h, k)
+	}
+
+	for attempt := 0; attempt < holesAttempts; attempt++ {
+		rng.Shuffle(len(pairs), func(i, j int) { pairs[i], pairs[j] = pairs[j], pairs[i] })
+
+		holes := make([]string, 0, k)
+		for _, pair := range pairs[:k/2] {
+			for _, cell := range pair {
+				holes = append(holes, cellToPosition(w, cell).String())
+			}
+		}
+
+		v.Holes = holes
+		if playableStart(w, h, v) {
+			return holes, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Failed to place %d holes where the first player can place", k)
+}
+
+// playableStart tells that black has a legal move at every start of the variant
+func playableStart(w, h int, v Variant) bool {
+	starts := []string{v.Start}
+	if v.Opening == OpeningRandom && v.Start == "" {
+		starts = randomStarts
+	}
+
+	for _, start := range starts {
+		v.Start = start
+		if !NewRectBoard(w, h, v).HasLegalMove(Black) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LoadHoles reads the holes from a file of rows like "--#--#--", where # is a hole.
+// Lines starting with "//" are comments
+func LoadHoles(path string, w, h int) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read holes: %w", err)
+	}
+
+	rows := make([]string, 0, h)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		rows = append(rows, line)
+	}
+
+	if len(rows) != h {
+		return nil, fmt.Errorf("Holes %s must have %d rows for %dx%d: %d", path, h, w, h, len(rows))
+	}
+
+	holes := make([]string, 0)
+
+	for y, row := range rows {
+		if len(row) != w {
+			return nil, fmt.Errorf("Row %d of holes %s must have %d cells: %q", y+1, path, w, row)
+		}
+
+		for x, c := range row {
+			switch string(c) {
+			case HoleMark:
+				holes = append(holes, Position{x, y}.String())
+			case PipeNothing:
+			default:
+				return nil, fmt.Errorf("Row %d of holes %s has an unknown cell %q", y+1, path, c)
+			}
+		}
+	}
+
+	if err := validateHoles(w, h, holes); err != nil {
+		return nil, fmt.Errorf("Invalid holes %s: %w", path, err)
+	}
+
+	return holes, nil
+}
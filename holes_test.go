@@ -0,0 +1,129 @@
+package main
+
+import (
+	"log/slog"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoardHoles(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoardWithVariant(6, Variant{Holes: []string{"e3", "b4"}})
+
+	// e3 would flip d3
+	assert.False(t, b.IsLegal(4+2*6, Black))
+	assert.True(t, b.IsHole(Position{4, 2}))
+	assert.Equal(t, 36-4-2, b.CountEmptyCells())
+	assert.Equal(t, []string{"------", "------", "--XO#-", "-#OX--", "------", "------"}, boardRows(b))
+
+	// copies have the same holes
+	placed, err := b.Place(Position{3, 1})
+	assert.NoError(t, err)
+	assert.True(t, placed.IsHole(Position{1, 3}))
+
+	// a hole breaks the line like an empty cell
+	broken, err := parseBoardRows("-O#X/----/----/----", Black)
+	assert.NoError(t, err)
+	assert.False(t, broken.IsLegal(0, Black))
+	assert.Equal(t, "-O#X", boardRows(broken)[0])
+
+	filled, _ := parseBoardRows("-OOX/----/----/----", Black)
+	assert.True(t, filled.IsLegal(0, Black))
+}
+
+func TestGameEndsWithHoles(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	// the last empty cell is taken, and only the hole is left
+	b, err := parseBoardRows("XOO/OOO/#O-", Black)
+	assert.NoError(t, err)
+
+	g := NewGame(b, Human, Human)
+	g.place(Position{2, 2})
+
+	assert.Equal(t, Finished, g.State)
+	assert.Equal(t, 0, g.Board.CountEmptyCells())
+	assert.Equal(t, "Black 3, White 5, Player 2 won ✨", g.Message)
+}
+
+func TestRandomHoles(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	rng := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{5, 8} {
+		holes, err := randomHoles(n, n, 4, Variant{}, rng)
+		assert.NoError(t, err)
+		assert.Len(t, holes, 4)
+		assert.NoError(t, validateHoles(n, n, holes))
+
+		// symmetrical about the centre
+		for _, h := range holes {
+			p, _ := parseCoordinate(h)
+			assert.Contains(t, holes, Position{n - 1 - p.X, n - 1 - p.Y}.String())
+		}
+
+		assert.True(t, NewBoardWithVariant(n, Variant{Holes: holes}).HasLegalMove(Black))
+	}
+
+	_, err := randomHoles(8, 8, 3, Variant{}, rng)
+	assert.EqualError(t, err, "-holes must be an even number up to 60 on 8x8: 3")
+}
+
+func TestLoadHoles(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	path := filepath.Join(t.TempDir(), "holes.txt")
+	os.WriteFile(path, []byte("// corners\n#----#\n------\n------\n------\n------\n#----#\n"), 0644)
+
+	holes, err := LoadHoles(path, 6, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a1", "f1", "a6", "f6"}, holes)
+
+	_, err = LoadHoles(path, 8, 8)
+	assert.ErrorContains(t, err, "must have 8 rows for 8x8: 6")
+
+	os.WriteFile(path, []byte("---\n-#-\n---\n"), 0644)
+	_, err = LoadHoles(path, 3, 3)
+	assert.ErrorContains(t, err, "Hole b2 is on the centre")
+}
+
+func TestRecordHoles(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	g := NewGame(NewBoardWithVariant(6, Variant{Holes: []string{"e3", "b4"}}), Human, Human)
+	g.place(Position{3, 1})
+
+	r := g.Record()
+	assert.Equal(t, []string{"e3", "b4"}, r.Holes)
+
+	boards, _, err := r.Positions()
+	assert.NoError(t, err)
+	assert.True(t, boards[1].IsHole(Position{4, 2}))
+
+	// a hole can't be placed on
+	r.Moves = []string{"e3"}
+	_, _, err = r.Positions()
+	assert.EqualError(t, err, "Move 1 (e3) is illegal: You can't place there.")
+
+	r.Holes = []string{"c3"}
+	_, _, err = r.Positions()
+	assert.EqualError(t, err, "Invalid variant: Hole c3 is on the centre")
+}
+
+func TestDisplayDrawsHoles(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	g := NewGame(NewBoardWithVariant(4, Variant{Holes: []string{"a1", "d4"}}), Human, Human)
+
+	lines := buildLines(&g, Position{3, 3}, nil, 20)
+
+	assert.True(t, strings.HasPrefix(lines[4], Spacer+RightWallString+" "+HoleString))
+	assert.True(t, strings.HasSuffix(lines[7], "|"+HoleString+LeftWallString))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type KeyAction string
+
+const (
+	ActionNone     KeyAction = ""
+	ActionLeft     KeyAction = "left"
+	ActionRight    KeyAction = "right"
+	ActionUp       KeyAction = "up"
+	ActionDown     KeyAction = "down"
+	ActionPlace    KeyAction = "place"
+	ActionAnalyze  KeyAction = "analyze"
+	ActionHint     KeyAction = "hint"
+	ActionPosition KeyAction = "position"
+	ActionReview   KeyAction = "review"
+	ActionReplay   KeyAction = "replay"
+	ActionQuit     KeyAction = "quit"
+)
+
+// the order to look up keys
+var keyActions = []KeyAction{
+	ActionLeft,
+	ActionRight,
+	ActionUp,
+	ActionDown,
+	ActionPlace,
+	ActionAnalyze,
+	ActionHint,
+	ActionPosition,
+	ActionReview,
+	ActionReplay,
+	ActionQuit,
+}
+
+// KeyBindings maps an action to its keys. Each character is a key, and the first one is shown in the help
+type KeyBindings map[KeyAction]string
+
+func defaultKeyBindings() KeyBindings {
+	return KeyBindings{
+		ActionLeft:     "ah",
+		ActionRight:    "dl",
+		ActionUp:       "wk",
+		ActionDown:     "sj",
+		ActionPlace:    " ",
+		ActionAnalyze:  "e",
+		ActionHint:     "?",
+		ActionPosition: "p",
+		ActionReview:   "v",
+		ActionReplay:   "r",
+		ActionQuit:     "c",
+	}
+}
+
+// key bindings in use, set from the config at startup
+var keyBindings = defaultKeyBindings()
+
+// keyAction returns the action bound to the key. Ctrl + C always quits
+func keyAction(char string) KeyAction {
+	if char == "\x03" {
+		return ActionQuit
+	}
+
+	for _, action := range keyActions {
+		if strings.Contains(keyBindings[action], char) {
+			return action
+		}
+	}
+
+	return ActionNone
+}
+
+// keyLabel returns the key shown in the help
+func keyLabel(action KeyAction) string {
+	keys := []rune(keyBindings[action])
+	if len(keys) == 0 {
+		return "-"
+	}
+
+	if keys[0] == ' ' {
+		return "<space>"
+	}
+
+	return string(keys[0])
+}
+
+// validate checks that the actions are known and no key is bound to two actions
+func (kb KeyBindings) validate() error {
+	usedBy := make(map[rune]KeyAction)
+
+	for action, keys := range kb {
+		known := false
+		for _, a := range keyActions {
+			known = known || a == action
+		}
+		if !known {
+			return fmt.Errorf("Unknown key action %q", action)
+		}
+
+		for _, key := range keys {
+			if other, ok := usedBy[key]; ok && other != action {
+				return fmt.Errorf("Key %q is bound to both %s and %s", key, other, action)
+			}
+			usedBy[key] = action
+		}
+	}
+
+	return nil
+}
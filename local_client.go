@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"sync"
 	"time"
@@ -15,6 +16,7 @@ type LocalClient struct {
 	closeCliCh chan<- bool
 	inputCh    <-chan string
 	PlayerId   PlayerId
+	Name       string // sent to the game if not empty
 	d          Renderer
 	p          *Position
 }
@@ -49,25 +51,30 @@ func (c *LocalClient) Run() {
 	go func() {
 	localClientInputLoop:
 		for char := range c.inputCh {
-			switch char {
+			action := keyAction(char)
+
+			switch action {
 			// move position
-			case "h", "a": // ←
-				c.p.addX(-1, g.Board.N)
+			case ActionLeft: // ←
+				c.p.addX(-1, g.Board.W)
 				c.d.Render(&g, *c.p)
 				continue localClientInputLoop
-			case "l", "d": // →
-				c.p.addX(1, g.Board.N)
+			case ActionRight: // →
+				c.p.addX(1, g.Board.W)
 				c.d.Render(&g, *c.p)
 				continue localClientInputLoop
-			case "j", "s": // ↓
-				c.p.addY(1, g.Board.N)
+			case ActionDown: // ↓
+				c.p.addY(1, g.Board.H)
 				c.d.Render(&g, *c.p)
 				continue localClientInputLoop
-			case "k", "w": // ↑
-				c.p.addY(-1, g.Board.N)
+			case ActionUp: // ↑
+				c.p.addY(-1, g.Board.H)
 				c.d.Render(&g, *c.p)
 				continue localClientInputLoop
-			case "c": // quit
+			case ActionPosition:
+				showPosition(g.Board, c.d)
+				continue localClientInputLoop
+			case ActionQuit:
 				go func() { c.quitCh <- true }()
 				c.closeCliCh <- true
 				logger.Info("Program finished.")
@@ -75,19 +82,29 @@ func (c *LocalClient) Run() {
 			}
 
 			if g.IsMyTurn(c.PlayerId) {
-				switch char {
-				case " ": // place
+				switch action {
+				case ActionPlace:
 					cmd := GameCommand{CommandType: CommandPlace, Position: *c.p}
 					go func() { c.cmdCh <- cmd }()
+				case ActionAnalyze:
+					showAnalysis(g.Board, c.d)
+				case ActionHint:
+					if p, ok := findHint(g.Board, c.d); ok {
+						*c.p = p
+						cmd := GameCommand{CommandType: CommandHint, Position: p}
+						go func() { c.cmdCh <- cmd }()
+					}
 				}
 				continue localClientInputLoop
 			}
 
 			if g.State == Finished {
-				switch char {
-				case "r":
+				switch action {
+				case ActionReplay:
 					cmd := GameCommand{CommandType: CommandReplay}
 					go func() { c.cmdCh <- cmd }()
+				case ActionReview:
+					showReview(&g, c.d)
 				}
 				continue localClientInputLoop
 			}
@@ -105,7 +122,7 @@ func (c *LocalClient) Run() {
 		if g.State == WaitingConnection &&
 			g.GetPlayer(c.PlayerId).Ready == false {
 			go func() {
-				c.cmdCh <- GameCommand{CommandType: CommandConnectionCheck}
+				c.cmdCh <- GameCommand{CommandType: CommandConnectionCheck, Name: c.Name}
 			}()
 		}
 
@@ -178,58 +195,93 @@ func (c *LocalMultiClient) Run() {
 
 localMultiClientInputLoop:
 	for char := range c.inputCh {
+		action := keyAction(char)
+
+		if action == ActionPosition {
+			showPosition(g.Board, c.d)
+		}
+
 		if g.State == Player1Turn || g.State == Player2Turn {
-			switch char {
+			switch action {
 			// move position
-			case "h", "a": // ←
-				c.p.addX(-1, g.Board.N)
+			case ActionLeft: // ←
+				c.p.addX(-1, g.Board.W)
 				c.d.Render(&g, *c.p)
-			case "l", "d": // →
-				c.p.addX(1, g.Board.N)
+			case ActionRight: // →
+				c.p.addX(1, g.Board.W)
 				c.d.Render(&g, *c.p)
-			case "j", "s": // ↓
-				c.p.addY(1, g.Board.N)
+			case ActionDown: // ↓
+				c.p.addY(1, g.Board.H)
 				c.d.Render(&g, *c.p)
-			case "k", "w": // ↑
-				c.p.addY(-1, g.Board.N)
+			case ActionUp: // ↑
+				c.p.addY(-1, g.Board.H)
 				c.d.Render(&g, *c.p)
 
 			// place
-			case " ":
+			case ActionPlace:
 				cmd := GameCommand{CommandType: CommandPlace, Position: *c.p}
 				if g.State == Player1Turn {
 					go func() { c.cmdCh1 <- cmd }()
 				} else {
 					go func() { c.cmdCh2 <- cmd }()
 				}
+
+			case ActionAnalyze:
+				showAnalysis(g.Board, c.d)
+
+			case ActionHint:
+				if p, ok := findHint(g.Board, c.d); ok {
+					*c.p = p
+					cmd := GameCommand{CommandType: CommandHint, Position: p}
+					if g.State == Player1Turn {
+						go func() { c.cmdCh1 <- cmd }()
+					} else {
+						go func() { c.cmdCh2 <- cmd }()
+					}
+				}
 			}
 		}
 
 		if g.State == Finished {
-			switch char {
-			case "r":
+			switch action {
+			case ActionReplay:
 				cmd := GameCommand{CommandType: CommandReplay}
 				go func() { c.cmdCh1 <- cmd }()
+			case ActionReview:
+				showReview(&g, c.d)
 			}
 		}
 
-		if char == "c" {
+		if action == ActionQuit {
 			c.quitCh1 <- true
 			break localMultiClientInputLoop
 		}
 	}
 }
 
+// the default minimum length of the AI's turn
+const aiMinDelay = 700 * time.Millisecond
+
 type AiClient struct {
 	gameCh   chan Game
 	cmdCh    chan GameCommand
 	quitCh   chan bool
 	PlayerId PlayerId
-	p        *AiPlayer
+	p        Engine
+	MinDelay time.Duration // the AI's turn takes at least this long, so that people can follow it
+	ResultCh chan<- Game   // receives the game when it's finished, if not nil
+	Ponder   bool          // searches the replies during the opponent's turn
+
+	ponderKey    string // the board being pondered
+	ponderCancel context.CancelFunc
+	ponderDone   chan struct{}
+	ponderCache  map[string]Position // the move for each board after the opponent's move, by canonicalKey
+	ponderHits   int
 }
 
 func NewAiClient(
 	n int,
+	level int,
 	gameCh chan Game,
 	cmdCh chan GameCommand,
 	quitCh chan bool,
@@ -241,22 +293,40 @@ func NewAiClient(
 		cmdCh:    cmdCh,
 		quitCh:   quitCh,
 		PlayerId: id,
-		p:        NewAiPlayer(n),
+		p:        NewAiPlayerWithLevel(n, level),
+		MinDelay: aiMinDelay,
 	}
 }
 
 func (c *AiClient) Run() {
+	defer c.stopPondering()
+
 AiClientLoop:
 	for g := range c.gameCh {
+		opponentsTurn := (g.State == Player1Turn || g.State == Player2Turn) && !g.IsMyTurn(c.PlayerId)
+
+		// the game changed, e.g. the opponent placed, undid, or quit
+		if !opponentsTurn || positionKey(g.Board) != c.ponderKey {
+			c.stopPondering()
+		}
+
 		if g.IsMyTurn(c.PlayerId) {
 			cmd := c.placeWithMinimumLength(&g)
 			c.cmdCh <- cmd
 		}
 
+		if opponentsTurn && c.Ponder && c.ponderCancel == nil {
+			c.startPondering(g.Board)
+		}
+
 		if g.State == WaitingConnection {
 			c.cmdCh <- GameCommand{CommandType: CommandConnectionCheck}
 		}
 
+		if g.State == Finished && c.ResultCh != nil {
+			c.ResultCh <- g
+		}
+
 		if g.State == Quit {
 			break AiClientLoop
 		}
@@ -270,11 +340,14 @@ func (c *AiClient) placeWithMinimumLength(g *Game) GameCommand {
 	wg.Add(1)
 
 	go func() {
-		time.Sleep(700 * time.Millisecond)
+		time.Sleep(c.MinDelay)
 		wg.Done()
 	}()
 
-	p := c.p.getPosition(g.Board)
+	p, ok := c.pondered(g.Board)
+	if !ok {
+		p = c.p.getPosition(g.Board)
+	}
 
 	cmd := GameCommand{CommandType: CommandPlace, Position: p}
 
@@ -12,8 +12,9 @@ import (
 var _ = fmt.Sprint("")
 
 type MockDisplay struct {
-	g *Game
-	p Position
+	g      *Game
+	p      Position
+	notice string
 }
 
 func (m *MockDisplay) Render(g *Game, p Position) {
@@ -21,6 +22,10 @@ func (m *MockDisplay) Render(g *Game, p Position) {
 	m.p = p
 }
 
+func (m *MockDisplay) Notify(message string) {
+	m.notice = message
+}
+
 func (m *MockDisplay) Close() {
 }
 
@@ -80,6 +85,53 @@ func TestLocalClientPlaceDisk(t *testing.T) {
 	assert.Equal(t, Position{0, 0}, cmd.Position)
 }
 
+func TestLocalClientHint(t *testing.T) {
+	gameCh, cmdCh, _, inputCh, _, _, client := localClientTestInitChannels()
+
+	go client.Run()
+
+	b := NewBoard(4)
+
+	g := NewGame(b, Human, AI)
+	g.State = Player1Turn
+
+	gameCh <- g
+
+	time.Sleep(10 * time.Millisecond)
+
+	inputCh <- "?"
+	cmd := <-cmdCh
+
+	assert.Equal(t, CommandHint, cmd.CommandType)
+	assert.True(t, b.IsLegal(cmd.Position.X+cmd.Position.Y*b.N, b.Turn))
+	hint := cmd.Position
+
+	// the cursor is on the hint
+	inputCh <- " "
+	cmd = <-cmdCh
+	assert.Equal(t, CommandPlace, cmd.CommandType)
+	assert.Equal(t, hint, cmd.Position)
+}
+
+func TestLocalClientShowsPosition(t *testing.T) {
+	gameCh, _, _, inputCh, _, d, client := localClientTestInitChannels()
+
+	go client.Run()
+
+	g := NewGame(NewBoard(4), Human, AI)
+	g.State = Player2Turn
+
+	gameCh <- g
+
+	time.Sleep(10 * time.Millisecond)
+
+	// also on the opponent's turn
+	inputCh <- "p"
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, "📋  4x4 -----XO--OX----- X", d.notice)
+}
+
 func TestLocalClientQuit(t *testing.T) {
 	_, _, quitCh, inputCh, _, _, client := localClientTestInitChannels()
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -9,10 +10,19 @@ import (
 var logger *slog.Logger
 
 func NewLogger(level slog.Level) *slog.Logger {
+	return newLogger(&stdOutMod{}, level)
+}
+
+// NewStderrLogger is for the modes which use stdout for their output, e.g., pipe mode
+func NewStderrLogger(level slog.Level) *slog.Logger {
+	return newLogger(os.Stderr, level)
+}
+
+func newLogger(w io.Writer, level slog.Level) *slog.Logger {
 	// only show source in debug
 	addSource := level == slog.LevelDebug
 
-	logger := slog.New(slog.NewTextHandler(&stdOutMod{}, &slog.HandlerOptions{
+	logger := slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
 		AddSource: addSource,
 		Level:     level, // Set the desired log level
 		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
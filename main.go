@@ -1,10 +1,14 @@
 package main
 
 import (
-	"flag"
+	"errors"
 	"fmt"
+	"io"
+	"log"
 	"log/slog"
+	"os"
 	"sync"
+	"time"
 )
 
 // TODO:
@@ -16,69 +20,48 @@ import (
 var _ string = fmt.Sprint("test")
 
 const (
-	DEFAULT_N    = 8
-	DEFAULT_PORT = 4696
-)
-
-type GameMode int
-
-const (
-	Single GameMode = iota
-	LocalMulti
-	OnlineHost
-	OnlineGuest
+	DEFAULT_N            = 8
+	DEFAULT_PORT         = 4696
+	DEFAULT_SSH_PORT     = 2222
+	DEFAULT_SSH_HOST_KEY = "reversi_ssh_host_key"
 )
 
 func main() {
-	n := flag.Int("n", DEFAULT_N, "Dimension of the board. (Default: 8)")
-	playerNum := flag.Int("p", 1, "1 for Single Play, 2 for 2 Players. (Default: 1)")
-	server := flag.Bool("s", false, "Start game with server")
-	isDebugging := flag.Bool("d", false, "Debug info")
-	url := flag.String("url", "", "Specify game server url to connect")
-	port := flag.Int("port", DEFAULT_PORT, "Specify game server's port")
-
-	flag.Parse()
-
-	if *isDebugging {
-		logger = NewLogger(slog.LevelDebug)
-	} else {
-		logger = NewLogger(slog.LevelError)
+	cfg, err := loadConfig(configPath(os.Getenv), os.Getenv)
+	if err == nil {
+		err = cfg.apply()
 	}
-
-	gm := Single
-
-	if *server {
-		gm = OnlineHost
-	} else if *url != "" {
-		gm = OnlineGuest
-	} else if *playerNum == 2 {
-		gm = LocalMulti
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	switch gm {
-	case Single: // 2 players
-		startLocalSingleGame(*n)
-
-	case LocalMulti: // 2 players
-		startLocalMultiGame(*n)
-
-	case OnlineHost:
-		startHostClient(*n, *port)
-
-	case OnlineGuest:
-		startGuestClient(*url, *port)
+	err = runCli(os.Args[1:], &cfg, os.Stderr)
+	if err == nil {
+		return
+	}
 
-	default: // 1 player
-		startLocalSingleGame(*n)
+	var uErr *usageError
+	if errors.As(err, &uErr) {
+		os.Exit(2)
 	}
+
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
 }
 
-func startLocalSingleGame(n int) {
-	b := NewBoard(n)
+func startLocalSingleGame(w, h int, v Variant, ai Engine, ponder bool, evalBar bool, name string, recordPath string) error {
+	b := NewRectBoard(w, h, v)
 
 	d := NewDisplay()
 	defer d.Close()
 
+	if evalBar {
+		d.EnableEvalBar()
+	}
+
+	r := NewGameRecorder(d)
+
 	g := NewGame(b, Human, AI)
 
 	player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, player1QuitCh, player2QuitCh := g.Start()
@@ -87,7 +70,9 @@ func startLocalSingleGame(n int) {
 
 	go func() {
 		for {
-			d.Read(inputCh)
+			if err := d.Read(inputCh); err != nil {
+				log.Fatal(err)
+			}
 		}
 	}()
 
@@ -101,16 +86,20 @@ func startLocalSingleGame(n int) {
 		inputCh,
 		closeCliCh,
 		Player1Id,
-		&d,
+		r,
 	)
+	cli1.Name = name
 
 	cli2 := NewAiClient(
-		n,
+		max(w, h),
+		DEFAULT_AI_LEVEL,
 		player2GameCh,
 		player2CmdCh,
 		player2QuitCh,
 		Player2Id,
 	)
+	cli2.p = ai
+	cli2.Ponder = ponder
 
 	go func() {
 		cli1.Run()
@@ -121,23 +110,36 @@ func startLocalSingleGame(n int) {
 	}()
 
 	<-closeCliCh
+
+	return saveRecord(r, recordPath)
 }
 
-func startLocalMultiGame(n int) {
-	b := NewBoard(n)
+func startLocalMultiGame(w, h int, v Variant, evalBar bool, name string, recordPath string) error {
+	b := NewRectBoard(w, h, v)
 
 	d := NewDisplay()
 	defer d.Close()
 
+	if evalBar {
+		d.EnableEvalBar()
+	}
+
+	r := NewGameRecorder(d)
+
 	inputCh := make(chan string)
 
 	go func() {
 		for {
-			d.Read(inputCh)
+			if err := d.Read(inputCh); err != nil {
+				log.Fatal(err)
+			}
 		}
 	}()
 
 	g := NewGame(b, Human, Human)
+	if name != "" {
+		g.Player1.Name = name
+	}
 
 	player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, player1QuitCh, player2QuitCh := g.Start()
 
@@ -149,7 +151,7 @@ func startLocalMultiGame(n int) {
 		player2CmdCh,
 		player2QuitCh,
 		inputCh,
-		&d,
+		r,
 	)
 
 	var wg sync.WaitGroup
@@ -169,9 +171,20 @@ func startLocalMultiGame(n int) {
 	close(player2GameCh)
 	close(player1QuitCh)
 	close(player2QuitCh)
+
+	return saveRecord(r, recordPath)
 }
 
-func startHostClient(n int, port int) {
+// saveRecord saves the record of the game if the path is set
+func saveRecord(r *GameRecorder, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	return r.Save(path)
+}
+
+func startHostClient(b *Board, port int, name string) {
 
 	d := NewDisplay()
 	defer d.Close()
@@ -180,19 +193,22 @@ func startHostClient(n int, port int) {
 
 	go func() {
 		for {
-			d.Read(inputCh)
+			if err := d.Read(inputCh); err != nil {
+				log.Fatal(err)
+			}
 		}
 	}()
 
 	hs := HostStarter{
-		d:       &d,
+		d:       d,
 		inputCh: inputCh,
+		name:    name,
 	}
 
-	hs.Start(n, port)
+	hs.Start(b, port)
 }
 
-func startGuestClient(url string, port int) {
+func startGuestClient(url string, port int, name string) {
 	d := NewDisplay()
 	defer d.Close()
 
@@ -200,14 +216,248 @@ func startGuestClient(url string, port int) {
 
 	go func() {
 		for {
-			d.Read(inputCh)
+			if err := d.Read(inputCh); err != nil {
+				log.Fatal(err)
+			}
 		}
 	}()
 
 	gs := GuestStarter{
-		d:       &d,
+		d:       d,
 		inputCh: inputCh,
+		name:    name,
 	}
 
 	gs.Start(url, port)
 }
+
+func startPipeGame(w, h int, v Variant, playerNum int, ai Engine, ponder bool) error {
+	d := NewPipeDisplay(os.Stdout)
+
+	inputCh := make(chan string)
+	go ReadLines(os.Stdin, inputCh)
+
+	closeCliCh := make(chan bool)
+
+	var seats []PipeSeat
+
+	if playerNum == 2 {
+		g := NewGame(NewRectBoard(w, h, v), Human, Human)
+
+		player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, player1QuitCh, player2QuitCh := g.Start()
+
+		seats = []PipeSeat{
+			NewPipeSeat(player1GameCh, player1CmdCh, player1QuitCh, Player1Id),
+			NewPipeSeat(player2GameCh, player2CmdCh, player2QuitCh, Player2Id),
+		}
+	} else {
+		g := NewGame(NewRectBoard(w, h, v), Human, AI)
+
+		player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, player1QuitCh, player2QuitCh := g.Start()
+
+		seats = []PipeSeat{NewPipeSeat(player1GameCh, player1CmdCh, player1QuitCh, Player1Id)}
+
+		cli2 := NewAiClient(max(w, h), DEFAULT_AI_LEVEL, player2GameCh, player2CmdCh, player2QuitCh, Player2Id)
+		cli2.p = ai
+		cli2.Ponder = ponder
+		go cli2.Run()
+	}
+
+	cli := NewPipeClient(seats, inputCh, closeCliCh, d)
+	go cli.Run()
+
+	<-closeCliCh
+
+	return nil
+}
+
+func startPipeGuest(url string, port int) error {
+	d := NewPipeDisplay(os.Stdout)
+
+	inputCh := make(chan string)
+	go ReadLines(os.Stdin, inputCh)
+
+	closeCliCh := make(chan bool)
+
+	conn, gameCh, cmdCh, quitCh := NewOnlineGuestConnection(Player2Id, url, port)
+
+	go func() {
+		if err := conn.Run(); err != nil {
+			d.Respond(fmt.Sprintf("error can't connect %s", conn.Url))
+			logger.Debug("Error on guest conn", slog.Any("err", err))
+		}
+	}()
+	defer conn.Close()
+
+	seats := []PipeSeat{NewPipeSeat(gameCh, cmdCh, quitCh, Player2Id)}
+
+	cli := NewPipeClient(seats, inputCh, closeCliCh, d)
+	go cli.Run()
+
+	<-closeCliCh
+
+	return nil
+}
+
+func startSshServer(w, h int, v Variant, port int, hostKeyPath string, level int) error {
+	s, err := NewSshServer(w, h, v, port, hostKeyPath)
+	if err != nil {
+		return err
+	}
+	s.AiLevel = level
+
+	fmt.Printf("SSH server is running on port %d. Connect with: ssh -p %d localhost\n", port, port)
+
+	return s.ListenAndServe()
+}
+
+func startAnalyze(b *Board, depth int, timeLimit time.Duration) error {
+	ap := newAiPlayerFor(b)
+
+	start := time.Now()
+	results := ap.Analyze(b, depth, timeLimit)
+
+	printAnalysis(os.Stdout, b, results, time.Since(start))
+
+	return nil
+}
+
+func startReplay(r Record, eval bool) error {
+	d := NewDisplay()
+	defer d.Close()
+
+	inputCh := make(chan string)
+
+	go func() {
+		for {
+			if err := d.Read(inputCh); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}()
+
+	v, err := NewReplayViewer(r, inputCh, d)
+	if err != nil {
+		return err
+	}
+	v.Eval = eval
+
+	v.Run()
+
+	// report the illegal move again after the display is closed
+	return v.err
+}
+
+func startReview(r Record, depth int, timeLimit time.Duration, asJson bool, outputPath string) error {
+	review, err := ReviewRecord(r, depth, timeLimit, nil)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if outputPath != "" {
+		f, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("Failed to create report: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if asJson {
+		return review.WriteJSON(w)
+	}
+
+	review.WriteText(w)
+	return nil
+}
+
+func startBench(n int, depth int, searchDepth int, threads int) error {
+	err := runPerft(os.Stdout, n, depth)
+
+	if searchDepth > 0 {
+		fmt.Println()
+		runSearchBench(os.Stdout, n, searchDepth, threads)
+
+		if n == 8 {
+			fmt.Println()
+			if err := runOrderingBench(os.Stdout, searchDepth); err != nil {
+				return err
+			}
+		}
+	}
+
+	return err
+}
+
+func startTournament(t Tournament, csvPath string) error {
+	result, err := t.Run(func(done, total int) {
+		fmt.Fprintf(os.Stderr, "\rPlayed %d/%d games", done, total)
+	})
+	fmt.Fprintln(os.Stderr)
+
+	if err != nil {
+		return err
+	}
+
+	result.WriteText(os.Stdout)
+
+	if csvPath == "" {
+		return nil
+	}
+
+	f, err := os.Create(csvPath)
+	if err != nil {
+		return fmt.Errorf("Failed to create CSV: %w", err)
+	}
+	defer f.Close()
+
+	return result.WriteCSV(f)
+}
+
+func startTune(t Tuner, records []Record, outputPath string) error {
+	records = append(records, t.SelfPlay()...)
+
+	samples, err := t.Samples(records)
+	if err != nil {
+		return err
+	}
+
+	if t.Patterns {
+		return startTunePatterns(t, samples, outputPath)
+	}
+
+	weights, err := loadWeightsOrEmpty(outputPath)
+	if err != nil {
+		return err
+	}
+
+	result := t.Fit(samples)
+	result.WriteText(os.Stdout)
+
+	weights[t.N] = result.Table
+	if err := weights.Save(outputPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nSaved to %s. Set weights_file in the config to use it\n", outputPath)
+	return nil
+}
+
+func startTunePatterns(t Tuner, samples []TuneSample, outputPath string) error {
+	patterns, err := loadPatternWeightsOrEmpty(outputPath)
+	if err != nil {
+		return err
+	}
+
+	result := t.FitPatterns(samples)
+	result.WriteText(os.Stdout)
+
+	patterns[t.N] = result.Tables
+	if err := patterns.Save(outputPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nSaved to %s. Set pattern_file in the config to use it\n", outputPath)
+	return nil
+}
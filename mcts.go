@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	ENGINE_ALPHABETA = "alphabeta"
+	ENGINE_MCTS      = "mcts"
+
+	DEFAULT_EXPLORATION = math.Sqrt2
+	DEFAULT_PLAYOUT     = PlayoutHeuristic
+
+	// the search of the AI in a game stops at this time even if it hasn't finished the iterations
+	mctsGameTimeLimit = 3 * time.Second
+
+	// the rate of random moves in heuristic playouts
+	heuristicRandomRate = 0.1
+)
+
+// iterations for each AI level. A single playout of level 1 places randomly like the alpha-beta engine
+var mctsLevelIterations = [MAX_AI_LEVEL + 1]int{1: 1, 2: 200, 3: 1000, 4: 3000, 5: 10000}
+
+// Engine chooses the move of the AI
+type Engine interface {
+	getPosition(b *Board) Position
+}
+
+// newEngine returns the engine of the name for the AI level. The alpha-beta engine searches on the threads
+func newEngine(name string, w, h int, level int, threads int) (Engine, error) {
+	switch name {
+	case ENGINE_ALPHABETA:
+		ap := NewRectAiPlayer(w, h, level)
+		ap.Threads = threads
+		return ap, nil
+	case ENGINE_MCTS:
+		mp := NewMctsPlayer(max(w, h))
+		mp.Iterations = mctsLevelIterations[level]
+		mp.TimeLimit = mctsGameTimeLimit
+		return mp, nil
+	default:
+		return nil, fmt.Errorf("Unknown engine %q: it must be %s or %s", name, ENGINE_ALPHABETA, ENGINE_MCTS)
+	}
+}
+
+type PlayoutPolicy string
+
+const (
+	PlayoutRandom    PlayoutPolicy = "random"    // every legal move is equally likely
+	PlayoutHeuristic PlayoutPolicy = "heuristic" // takes corners, avoids the cells next to empty corners
+)
+
+func parsePlayoutPolicy(s string) (PlayoutPolicy, error) {
+	switch PlayoutPolicy(s) {
+	case PlayoutRandom, PlayoutHeuristic:
+		return PlayoutPolicy(s), nil
+	}
+	return "", fmt.Errorf("Unknown playout %q: it must be %s or %s", s, PlayoutRandom, PlayoutHeuristic)
+}
+
+// MctsPlayer chooses the move by Monte Carlo tree search with UCT.
+// It needs no evaluation table, so it plays on any board size
+type MctsPlayer struct {
+	N           int
+	Exploration float64 // the constant of UCT. Larger explores less visited moves more
+	Playout     PlayoutPolicy
+	Iterations  int           // the search stops after this number of playouts. 0 for no limit
+	TimeLimit   time.Duration // the search stops after this time. 0 for no limit
+
+	rng        *rand.Rand
+	iterations int             // the playouts of the last search
+	ctx        context.Context // stops the search when it's cancelled, if not nil
+}
+
+func NewMctsPlayer(n int) *MctsPlayer {
+	return &MctsPlayer{
+		N:           n,
+		Exploration: DEFAULT_EXPLORATION,
+		Playout:     DEFAULT_PLAYOUT,
+		TimeLimit:   time.Second,
+		rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// mctsNode is a position in the search tree
+type mctsNode struct {
+	board    *Board
+	move     Position
+	mover    Turn // the player who moved to this position
+	parent   *mctsNode
+	children []*mctsNode
+	untried  []Position // legal moves without a child yet
+	mustPass bool       // the player to move has no legal move, and the pass has no child yet
+	visits   int
+	wins     float64 // for the mover, a draw is a half
+}
+
+func newMctsNode(b *Board, move Position, parent *mctsNode) *mctsNode {
+	node := &mctsNode{board: b, move: move, mover: !b.Turn, parent: parent}
+
+	node.untried = legalPositions(b)
+	if len(node.untried) == 0 && b.HasLegalMove(!b.Turn) {
+		node.mustPass = true
+	}
+
+	return node
+}
+
+func (n *mctsNode) expanded() bool {
+	return len(n.untried) == 0 && !n.mustPass
+}
+
+// uct is the score to select the child, which balances the win rate and the number of visits
+func (n *mctsNode) uct(exploration float64) float64 {
+	return n.wins/float64(n.visits) + exploration*math.Sqrt(math.Log(float64(n.parent.visits))/float64(n.visits))
+}
+
+func (mp *MctsPlayer) getPosition(b *Board) Position {
+	if mp.Iterations == 0 && mp.TimeLimit == 0 {
+		mp.TimeLimit = time.Second
+	}
+
+	root := newMctsNode(b.CopyBoard(), Position{}, nil)
+	if len(root.untried) == 1 {
+		return root.untried[0]
+	}
+
+	start := time.Now()
+	mp.iterations = 0
+
+	for mp.Iterations == 0 || mp.iterations < mp.Iterations {
+		// at least one playout to have a move
+		if mp.iterations > 0 && (mp.TimeLimit > 0 && time.Since(start) >= mp.TimeLimit || mp.ctx != nil && mp.ctx.Err() != nil) {
+			break
+		}
+
+		mp.iterate(root)
+		mp.iterations++
+	}
+
+	// the most visited move is the most reliable
+	best := root.children[0]
+	for _, child := range root.children {
+		if child.visits > best.visits {
+			best = child
+		}
+	}
+
+	logger.Debug("mcts", slog.Any("best", best.move), slog.Int("visits", best.visits), slog.Int("iterations", mp.iterations))
+
+	return best.move
+}
+
+// iterate selects a leaf by UCT, expands it, plays it out, and updates the nodes on the way
+func (mp *MctsPlayer) iterate(root *mctsNode) {
+	node := root
+
+	for node.expanded() && len(node.children) > 0 {
+		best := node.children[0]
+		bestScore := best.uct(mp.Exploration)
+		for _, child := range node.children[1:] {
+			if score := child.uct(mp.Exploration); score > bestScore {
+				best, bestScore = child, score
+			}
+		}
+		node = best
+	}
+
+	if len(node.untried) > 0 {
+		i := mp.rng.Intn(len(node.untried))
+		p := node.untried[i]
+		node.untried = append(node.untried[:i], node.untried[i+1:]...)
+
+		placed, _ := node.board.Place(p)
+		child := newMctsNode(placed, p, node)
+		node.children = append(node.children, child)
+		node = child
+	} else if node.mustPass {
+		passed := node.board.CopyBoard()
+		passed.SwitchTurn()
+
+		child := newMctsNode(passed, Position{}, node)
+		node.children = append(node.children, child)
+		node.mustPass = false
+		node = child
+	}
+
+	winner, draw := mp.playout(node.board)
+
+	for ; node != nil; node = node.parent {
+		node.visits++
+		if draw {
+			node.wins += 0.5
+		} else if winner == node.mover {
+			node.wins++
+		}
+	}
+}
+
+// playout plays the game to the end by the policy, and returns the winner by the rule of the variant
+func (mp *MctsPlayer) playout(b *Board) (Turn, bool) {
+	for {
+		positions := legalPositions(b)
+
+		if len(positions) == 0 {
+			if !b.HasLegalMove(!b.Turn) {
+				break
+			}
+
+			b = b.CopyBoard()
+			b.SwitchTurn()
+			continue
+		}
+
+		var p Position
+		if mp.Playout == PlayoutHeuristic {
+			p = heuristicMove(b, positions, mp.rng)
+		} else {
+			p = positions[mp.rng.Intn(len(positions))]
+		}
+
+		b, _ = b.Place(p)
+	}
+
+	result := b.Result()
+	if result < 0 {
+		return White, false
+	}
+	return Black, result == 0
+}
+
+// heuristicMove takes a corner if it can, avoids the cells next to empty corners, and prefers the edges.
+// It plays randomly sometimes so that the playouts don't repeat
+func heuristicMove(b *Board, positions []Position, rng *rand.Rand) Position {
+	if rng.Float64() < heuristicRandomRate {
+		return positions[rng.Intn(len(positions))]
+	}
+
+	best := make([]Position, 0, len(positions))
+	bestScore := math.MinInt
+
+	for _, p := range positions {
+		score := cellHeuristic(b, p)
+
+		if score > bestScore {
+			best = best[:0]
+			bestScore = score
+		}
+		if score == bestScore {
+			best = append(best, p)
+		}
+	}
+
+	return best[rng.Intn(len(best))]
+}
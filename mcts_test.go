@@ -0,0 +1,119 @@
+package main
+
+import (
+	"log/slog"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSeededMctsPlayer(n int, iterations int) *MctsPlayer {
+	mp := NewMctsPlayer(n)
+	mp.Iterations = iterations
+	mp.TimeLimit = 0
+	mp.rng = rand.New(rand.NewSource(1))
+	return mp
+}
+
+func TestMctsPlayerLegalMove(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	for _, n := range []int{3, 5, 7, 8} {
+		for _, playout := range []PlayoutPolicy{PlayoutRandom, PlayoutHeuristic} {
+			b := NewBoard(n)
+			mp := newSeededMctsPlayer(n, 100)
+			mp.Playout = playout
+
+			p := mp.getPosition(b)
+
+			assert.True(t, b.IsLegal(p.X+p.Y*n, b.Turn), "%dx%d %s", n, n, playout)
+			assert.Equal(t, 100, mp.iterations)
+		}
+	}
+}
+
+func TestMctsPlayerSingleMove(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(3)
+	b.FromStringCells(
+		[][]string{
+			{"n", "w", "b"},
+			{"n", "n", "n"},
+			{"n", "n", "n"},
+		},
+	)
+
+	mp := newSeededMctsPlayer(3, 100)
+
+	// black has only a1, so no search is needed
+	assert.Equal(t, Position{0, 0}, mp.getPosition(b))
+	assert.Equal(t, 0, mp.iterations)
+}
+
+func TestMctsPlayerWinningMove(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(5)
+	b.FromStringCells(
+		[][]string{
+			{"b", "b", "b", "b", "n"},
+			{"w", "b", "b", "w", "w"},
+			{"w", "b", "w", "w", "n"},
+			{"w", "w", "w", "w", "b"},
+			{"w", "b", "b", "n", "n"},
+		},
+	)
+
+	// only e5 wins. e3 and d5 lose
+	mp := newSeededMctsPlayer(5, 2000)
+	assert.Equal(t, Position{4, 4}, mp.getPosition(b))
+}
+
+func TestMctsPlayerPlayout(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	for _, playout := range []PlayoutPolicy{PlayoutRandom, PlayoutHeuristic} {
+		mp := newSeededMctsPlayer(6, 1)
+		mp.Playout = playout
+
+		b := NewBoard(6)
+		mp.playout(b)
+
+		// the playout doesn't change the board
+		assert.Equal(t, NewBoard(6).Lines, b.Lines)
+	}
+
+	// the player with more discs wins
+	b := NewBoard(3)
+	b.FromStringCells(
+		[][]string{
+			{"w", "w", "w"},
+			{"w", "b", "w"},
+			{"w", "w", "w"},
+		},
+	)
+
+	winner, draw := newSeededMctsPlayer(3, 1).playout(b)
+	assert.Equal(t, White, winner)
+	assert.False(t, draw)
+}
+
+func TestNewEngine(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	e, err := newEngine(ENGINE_ALPHABETA, 8, 8, 3, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, e.(*AiPlayer).Threads)
+
+	e, err = newEngine(ENGINE_MCTS, 8, 8, 3, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, mctsLevelIterations[3], e.(*MctsPlayer).Iterations)
+
+	_, err = newEngine("minimax", 8, 8, 3, 2)
+	assert.EqualError(t, err, `Unknown engine "minimax": it must be alphabeta or mcts`)
+
+	_, err = parsePlayoutPolicy("smart")
+	assert.Error(t, err)
+}
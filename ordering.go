@@ -0,0 +1,184 @@
+package main
+
+import "sort"
+
+const (
+	orderCellWeight     = 100 // corners first, then edges, and the cells next to empty corners last
+	orderMobilityWeight = 10  // for each legal move the opponent has after the move
+	orderKillerScore    = 150 // the move caused a cutoff at the same depth
+
+	// nodes shallower than these search in the cell order, or without the mobility, as it costs more than it saves
+	orderMinDepth         = 2
+	orderMobilityMinDepth = 4
+)
+
+// orderedMove is a legal move. The board after the move is made when it's needed, as a cutoff skips the rest of the moves
+type orderedMove struct {
+	cell  int
+	board *Board
+	score int
+}
+
+// place returns the board after the move
+func (m *orderedMove) place(b *Board) *Board {
+	if m.board == nil {
+		m.board, _ = b.Place(cellToPosition(b.W, m.cell))
+	}
+	return m.board
+}
+
+// resetOrdering clears the killer moves and the history table before a search to the depth
+func (ap *AiPlayer) resetOrdering(depth int) {
+	ap.killers = make([][2]int, depth+1)
+	for i := range ap.killers {
+		ap.killers[i] = [2]int{-1, -1}
+	}
+
+	ap.history = [2][]int{make([]int, ap.W*ap.H), make([]int, ap.W*ap.H)}
+}
+
+// recordCutoff remembers the move that caused a beta cutoff, so that it's searched early in the other nodes
+func (ap *AiPlayer) recordCutoff(t Turn, cell int, depth int) {
+	if depth < len(ap.killers) && ap.killers[depth][0] != cell {
+		ap.killers[depth][1] = ap.killers[depth][0]
+		ap.killers[depth][0] = cell
+	}
+
+	if ap.history[0] != nil {
+		ap.history[turnIndex(t)][cell] += depth * depth
+	}
+}
+
+// legalMoves returns the legal moves in the cell order
+func legalMoves(b *Board) []orderedMove {
+	moves := make([]orderedMove, 0, b.CellN)
+
+	for cell := 0; cell < b.CellN; cell++ {
+		if b.IsLegal(cell, b.Turn) {
+			moves = append(moves, orderedMove{cell: cell})
+		}
+	}
+
+	return moves
+}
+
+// legalPositions returns the positions of the legal moves in the cell order
+func legalPositions(b *Board) []Position {
+	positions := make([]Position, 0)
+	for cell := 0; cell < b.CellN; cell++ {
+		if b.IsLegal(cell, b.Turn) {
+			positions = append(positions, cellToPosition(b.W, cell))
+		}
+	}
+	return positions
+}
+
+// cellHeuristic rates the cell: 3 for a corner, 2 for an edge, 0 next to an empty corner and 1 for the others
+func cellHeuristic(b *Board, p Position) int {
+	lastX, lastY := b.W-1, b.H-1
+	onEdgeX := p.X == 0 || p.X == lastX
+	onEdgeY := p.Y == 0 || p.Y == lastY
+
+	if onEdgeX && onEdgeY {
+		return 3
+	}
+
+	// the nearest corner
+	corner := Position{0, 0}
+	if p.X > lastX/2 {
+		corner.X = lastX
+	}
+	if p.Y > lastY/2 {
+		corner.Y = lastY
+	}
+
+	nextToCorner := abs(p.X-corner.X) <= 1 && abs(p.Y-corner.Y) <= 1
+	if nextToCorner && b.GetCellState(corner) == HasNothing && !b.IsHole(corner) {
+		return 0
+	}
+
+	if onEdgeX || onEdgeY {
+		return 2
+	}
+
+	return 1
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// scoreMoves scores the moves by the cell, and by the mobility of the opponent after the move if mobility is true
+func scoreMoves(b *Board, moves []orderedMove, mobility bool) {
+	for i := range moves {
+		m := &moves[i]
+		m.score = cellHeuristic(b, cellToPosition(b.W, m.cell)) * orderCellWeight
+		if mobility {
+			placed := m.place(b)
+			m.score -= placed.CountLegalMoves(placed.Turn) * orderMobilityWeight
+		}
+	}
+}
+
+// rootMoves returns the legal moves of the root in the order to search.
+// It doesn't use the killer moves or the history, so the order is the same for every thread
+func (ap *AiPlayer) rootMoves(b *Board) []orderedMove {
+	if ap.plainOrder {
+		return legalMoves(b)
+	}
+
+	return likelyMoves(b)
+}
+
+// likelyMoves returns the legal moves ordered by the cell and the mobility, the likely good moves first
+func likelyMoves(b *Board) []orderedMove {
+	moves := legalMoves(b)
+
+	scoreMoves(b, moves, true)
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].score > moves[j].score })
+
+	return moves
+}
+
+// orderedMoves returns the legal moves in the order to search.
+// The killer moves of the depth come early, and moves of the same score are ordered by the history
+func (ap *AiPlayer) orderedMoves(b *Board, depth int) []orderedMove {
+	moves := legalMoves(b)
+	if ap.plainOrder || depth < orderMinDepth {
+		return moves
+	}
+
+	scoreMoves(b, moves, depth >= orderMobilityMinDepth)
+
+	if depth < len(ap.killers) {
+		for i, m := range moves {
+			if m.cell == ap.killers[depth][0] || m.cell == ap.killers[depth][1] {
+				moves[i].score += orderKillerScore
+			}
+		}
+	}
+
+	history := ap.history[turnIndex(b.Turn)]
+
+	sort.SliceStable(moves, func(i, j int) bool {
+		if moves[i].score != moves[j].score {
+			return moves[i].score > moves[j].score
+		}
+		if history == nil {
+			return false
+		}
+		return history[moves[i].cell] > history[moves[j].cell]
+	})
+
+	return moves
+}
+
+func turnIndex(t Turn) int {
+	if t == White {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"log/slog"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderingKeepsScore(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	rng := rand.New(rand.NewSource(1))
+
+	plain := NewAiPlayer(6)
+	plain.depth = 4
+	plain.plainOrder = true
+
+	ordered := NewAiPlayer(6)
+	ordered.depth = 4
+	ordered.resetOrdering(ordered.depth)
+
+	b := NewBoard(6)
+	for b.HasLegalMove(b.Turn) {
+		var pv []Move
+		want := plain.negMax(b.CopyBoard(), 4, -9999, 9999, false, false, &pv)
+		got := ordered.negMax(b.CopyBoard(), 4, -9999, 9999, false, false, &pv)
+		assert.Equal(t, want, got, "\n%s", b)
+
+		b, _ = b.Place(randomMove(b, rng))
+	}
+}
+
+func TestCellHeuristic(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(6)
+
+	assert.Equal(t, 3, cellHeuristic(b, Position{5, 0}))
+	assert.Equal(t, 0, cellHeuristic(b, Position{1, 1}))
+	assert.Equal(t, 0, cellHeuristic(b, Position{4, 5}))
+	assert.Equal(t, 2, cellHeuristic(b, Position{2, 0}))
+	assert.Equal(t, 1, cellHeuristic(b, Position{2, 1}))
+}
+
+func TestOrderedMoves(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(4)
+	b.FromStringCells(
+		[][]string{
+			{"n", "w", "b", "n"},
+			{"n", "w", "w", "n"},
+			{"n", "b", "w", "n"},
+			{"n", "n", "n", "n"},
+		},
+	)
+
+	ap := NewAiPlayer(4)
+	ap.depth = 5
+	ap.resetOrdering(ap.depth)
+
+	cells := func(moves []orderedMove) []int {
+		c := make([]int, 0, len(moves))
+		for _, m := range moves {
+			c = append(c, m.cell)
+		}
+		return c
+	}
+
+	// the corners a1 and d1 first
+	assert.Equal(t, []int{0, 3, 8, 11, 14}, cells(legalMoves(b)))
+	assert.ElementsMatch(t, []int{0, 3}, cells(ap.orderedMoves(b, 4))[:2])
+
+	// the moves of the first board are the same by the cell and the mobility, so the cell order is kept
+	b = NewBoard(8)
+	ap = NewAiPlayer(8)
+	ap.depth = 5
+	ap.resetOrdering(ap.depth)
+	e3, f4, c5, d6 := 20, 29, 34, 43
+	assert.Equal(t, []int{e3, f4, c5, d6}, cells(ap.orderedMoves(b, 4)))
+
+	// a killer move comes first at the same depth, and the history orders the others
+	ap.recordCutoff(b.Turn, d6, 4)
+	ap.recordCutoff(b.Turn, c5, 2)
+	assert.Equal(t, []int{d6, c5, e3, f4}, cells(ap.orderedMoves(b, 4)))
+	assert.Equal(t, []int{d6, c5, e3, f4}, cells(ap.orderedMoves(b, 3)))
+
+	// the history is for each colour
+	b.SwitchTurn()
+	d3, e6 := 19, 44
+	assert.Equal(t, d3, cells(ap.orderedMoves(b, 3))[0])
+	ap.recordCutoff(b.Turn, e6, 2)
+	assert.Equal(t, e6, cells(ap.orderedMoves(b, 3))[0])
+
+	// shallow nodes are in the cell order
+	b.SwitchTurn()
+	assert.Equal(t, []int{e3, f4, c5, d6}, cells(ap.orderedMoves(b, 1)))
+}
+
+func TestGetBestPV(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(8)
+	ap := NewAiPlayer(8)
+	ap.depth = 5
+
+	p, pv := ap.getBest(b)
+
+	assert.Len(t, pv, 5)
+	assert.Equal(t, p, pv[0].Position)
+
+	// the variation is a line of legal moves
+	for _, m := range pv {
+		assert.Equal(t, b.Turn, m.Colour)
+		if m.Pass {
+			b = b.CopyBoard()
+			b.SwitchTurn()
+			continue
+		}
+
+		placed, err := b.Place(m.Position)
+		assert.NoError(t, err)
+		b = placed
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	PanelWidth  = 26
+	PassString  = "--"
+	panelGap    = "  "
+	sparkLevels = "▁▂▃▄▅▆▇█"
+)
+
+// buildPanel returns the lines of the panel showing the move list and the score graph.
+// The move list is cut from the top so that the panel fits in maxLines lines.
+func buildPanel(moves []Move, width, maxLines int) []string {
+	graph := []string{
+		fmt.Sprintf("Score (%s-%s)", BlackString, WhiteString),
+		sparkline(discDiffs(moves), width),
+	}
+
+	lines := []string{"Moves"}
+
+	moveLines := packEntries(formatMoves(moves), width)
+
+	// keep the latest moves visible
+	moveLineN := maxLines - len(lines) - len(graph) - 1
+	if moveLineN < 1 {
+		moveLineN = 1
+	}
+	if len(moveLines) > moveLineN {
+		moveLines = moveLines[len(moveLines)-moveLineN:]
+	}
+
+	lines = append(lines, moveLines...)
+	lines = append(lines, "")
+	lines = append(lines, graph...)
+
+	return lines
+}
+
+// formatMoves returns the numbered move list, black and white moves paired (e.g., "1. f5 d6")
+func formatMoves(moves []Move) []string {
+	entries := make([]string, 0, len(moves)/2+1)
+
+	moveNum := 1
+	for i := 0; i < len(moves); i++ {
+		black, white := "..", ""
+
+		if moves[i].Colour == Black {
+			black = formatMove(moves[i])
+			if i+1 < len(moves) && moves[i+1].Colour == White {
+				i++
+				white = formatMove(moves[i])
+			}
+		} else {
+			// the game started with white, or black has been skipped
+			white = formatMove(moves[i])
+		}
+
+		entries = append(entries, strings.TrimRight(fmt.Sprintf("%d. %-3s %s", moveNum, black, white), " "))
+		moveNum++
+	}
+
+	return entries
+}
+
+func formatMove(m Move) string {
+	if m.Pass {
+		return PassString
+	}
+	return m.Position.String()
+}
+
+// packEntries puts as many entries in one line as the width allows
+func packEntries(entries []string, width int) []string {
+	lines := make([]string, 0)
+
+	line := ""
+	for _, entry := range entries {
+		entry = padRight(entry, 11)
+
+		if line != "" && visibleLen(line)+visibleLen(entry) > width {
+			lines = append(lines, strings.TrimRight(line, " "))
+			line = ""
+		}
+		line += entry
+	}
+
+	if line != "" {
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+
+	return lines
+}
+
+func discDiffs(moves []Move) []int {
+	diffs := make([]int, 0, len(moves))
+
+	for _, m := range moves {
+		diffs = append(diffs, m.Black-m.White)
+	}
+
+	return diffs
+}
+
+// sparkline draws the latest values in a single line. The middle level is 0
+func sparkline(values []int, width int) string {
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+
+	maxAbs := 1
+	for _, v := range values {
+		maxAbs = max(maxAbs, v, -v)
+	}
+
+	levels := []rune(sparkLevels)
+	middle := (len(levels) - 1) / 2
+
+	var builder strings.Builder
+
+	for _, v := range values {
+		level := middle + v*(len(levels)-1-middle)/maxAbs
+		if v < 0 {
+			level = middle + v*middle/maxAbs
+		}
+		builder.WriteRune(levels[level])
+	}
+
+	return builder.String()
+}
+
+// wideRunes are the characters taking two columns in the terminal: emoji, and CJK characters in names
+var wideRunes = [][2]rune{
+	{0x1100, 0x115f},
+	{0x231a, 0x231b},
+	{0x23e9, 0x23ec},
+	{0x23f0, 0x23f0},
+	{0x23f3, 0x23f3},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267f, 0x267f},
+	{0x2693, 0x2693},
+	{0x26a1, 0x26a1},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x26ce, 0x26ce},
+	{0x26d4, 0x26d4},
+	{0x26ea, 0x26ea},
+	{0x26f2, 0x26f3},
+	{0x26f5, 0x26f5},
+	{0x26fa, 0x26fa},
+	{0x26fd, 0x26fd},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2728, 0x2728},
+	{0x274c, 0x274c},
+	{0x274e, 0x274e},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27b0, 0x27b0},
+	{0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50},
+	{0x2b55, 0x2b55},
+	{0x2e80, 0xa4cf},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe30, 0xfe4f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x1f300, 0x1f64f},
+	{0x1f680, 0x1f6ff},
+	{0x1f900, 0x1faff},
+	{0x20000, 0x3fffd},
+}
+
+// visibleLen counts the columns the text takes in the terminal.
+// Emoji are two columns wide, and the discs and the blocks of the board are one
+func visibleLen(s string) int {
+	n := 0
+	last := 0
+
+	for _, r := range s {
+		width := runeWidth(r)
+
+		// the emoji style selector makes the symbol before it wide, e.g., ⚠️
+		if r == 0xfe0f && last == 1 {
+			width = 1
+		}
+
+		n += width
+		last = width
+	}
+
+	return n
+}
+
+func runeWidth(r rune) int {
+	if r == 0x200d || (r >= 0xfe00 && r <= 0xfe0f) {
+		return 0
+	}
+
+	for _, wide := range wideRunes {
+		if r >= wide[0] && r <= wide[1] {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+func padRight(s string, width int) string {
+	if n := visibleLen(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
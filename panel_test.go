@@ -0,0 +1,79 @@
+package main
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPanelFormatMoves(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	moves := []Move{
+		{Colour: Black, Position: Position{5, 4}},
+		{Colour: White, Position: Position{3, 5}},
+		{Colour: Black, Position: Position{2, 2}},
+		{Colour: White, Pass: true},
+		{Colour: Black, Position: Position{0, 0}},
+	}
+
+	assert.Equal(t, []string{"1. f5  d6", "2. c3  --", "3. a1"}, formatMoves(moves))
+
+	// white moves first
+	moves = []Move{
+		{Colour: White, Position: Position{1, 1}},
+		{Colour: Black, Position: Position{2, 1}},
+	}
+
+	assert.Equal(t, []string{"1. ..  b2", "2. c2"}, formatMoves(moves))
+}
+
+func TestPanelPackEntries(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	entries := []string{"1. f5  d6", "2. c3  --", "3. a1"}
+
+	assert.Equal(t, []string{"1. f5  d6  2. c3  --", "3. a1"}, packEntries(entries, 22))
+	assert.Equal(t, []string{"1. f5  d6", "2. c3  --", "3. a1"}, packEntries(entries, 12))
+}
+
+func TestPanelVisibleLen(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	// the discs and the blocks are one column
+	assert.Equal(t, 4, visibleLen("●○▁█"))
+
+	// emoji are two columns
+	assert.Equal(t, 6, visibleLen("Bob 💡"))
+	assert.Equal(t, 11, visibleLen("⚠️  Blunder"))
+	assert.Equal(t, "🚨  |", padRight("🚨", 4)+"|")
+}
+
+func TestPanelSparkline(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	assert.Equal(t, "▄▁█▄", sparkline([]int{0, -4, 4, 0}, 10))
+
+	// only the latest values are drawn
+	assert.Equal(t, "▁█", sparkline([]int{0, -4, 4}, 2))
+}
+
+func TestPanelBuildPanelKeepsLatestMoves(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	moves := make([]Move, 0)
+	for i := 0; i < 20; i++ {
+		colour := Black
+		if i%2 == 1 {
+			colour = White
+		}
+		moves = append(moves, Move{Colour: colour, Position: Position{i % 8, i / 8}})
+	}
+
+	lines := buildPanel(moves, 11, 6)
+
+	assert.Equal(t, 6, len(lines))
+	assert.Equal(t, "Moves", lines[0])
+	assert.Equal(t, "10. c3  d3", lines[2])
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// getBestParallel searches the root moves on Threads goroutines, each with its own copy of the AI.
+// The boards and the score tables are only read, so they are shared.
+//
+// A move is searched with the window just below the best score so far, so that a move as good as the best still gets its exact score.
+// The first of the best moves in the root order is chosen, which is the move the serial search chooses
+func (ap *AiPlayer) getBestParallel(b *Board) (Position, []Move) {
+	ap.evalCount = 0
+	depth := ap.depth
+	canUseFinal := b.CountEmptyCells() < depth
+
+	moves := ap.rootMoves(b)
+
+	var mu sync.Mutex
+	bestCell, bestPV := firstMove(b, moves)
+	bestIndex := len(moves)
+	alpha := -9999
+	beta := 9999
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < min(ap.Threads, len(moves)); w++ {
+		worker := *ap
+		worker.resetOrdering(depth)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				if ap.cancelled() {
+					continue
+				}
+
+				m := &moves[i]
+				placed := m.place(b)
+
+				mu.Lock()
+				bound := alpha - 1
+				mu.Unlock()
+
+				var pv []Move
+
+				// a null window only tells if the move is as good as the best so far
+				score := -worker.negMax(placed, depth-1, -bound-1, -bound, false, canUseFinal, &pv)
+				if score > bound {
+					score = -worker.negMax(placed, depth-1, -beta, -bound, false, canUseFinal, &pv)
+				}
+
+				if ap.cancelled() {
+					continue
+				}
+
+				logger.Debug("point ", slog.Any("cell", ap.cellToPosition(m.cell)), slog.Int("score", score))
+
+				mu.Lock()
+				if score > alpha || score == alpha && i < bestIndex {
+					bestCell = m.cell
+					bestIndex = i
+					bestPV = append([]Move{{Colour: b.Turn, Position: ap.cellToPosition(m.cell)}}, pv...)
+					alpha = score
+				}
+				mu.Unlock()
+			}
+
+			mu.Lock()
+			ap.evalCount += worker.evalCount
+			mu.Unlock()
+		}()
+	}
+
+	for i := range moves {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	logger.Debug("best  ", slog.Any("cell", ap.cellToPosition(bestCell)), slog.Int("score", alpha))
+
+	logger.Debug("evaluated ", slog.Int("evalCount", ap.evalCount), slog.Int("threads", ap.Threads))
+	return ap.cellToPosition(bestCell), bestPV
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBestParallel(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	for _, n := range []int{4, 6, 8} {
+		rng := rand.New(rand.NewSource(int64(n)))
+
+		serial := NewAiPlayer(n)
+		serial.depth = 3
+
+		parallel := NewAiPlayer(n)
+		parallel.depth = 3
+		parallel.Threads = 4
+
+		// positions through random games, including the ones near the end solved by the final score
+		b := NewBoard(n)
+		for {
+			if !b.HasLegalMove(b.Turn) {
+				if !b.HasLegalMove(!b.Turn) {
+					break
+				}
+				b = b.CopyBoard()
+				b.SwitchTurn()
+				continue
+			}
+
+			want, _ := serial.getBest(b)
+			got, pv := parallel.getBest(b)
+			assert.Equal(t, want, got, "%dx%d\n%s", n, n, b)
+			assert.Equal(t, got, pv[0].Position)
+			assert.Greater(t, parallel.evalCount, 0)
+
+			b, _ = b.Place(randomMove(b, rng))
+		}
+	}
+}
+
+func TestGetBestParallelSameScores(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	// every move of the first board has the same score, so the first move in the root order is chosen
+	b := NewBoard(8)
+
+	ap := NewAiPlayer(8)
+	ap.depth = 1
+	want, _ := ap.getBest(b)
+
+	for threads := 2; threads <= 4; threads++ {
+		ap.Threads = threads
+		got, _ := ap.getBest(b)
+		assert.Equal(t, want, got)
+	}
+}
+
+func TestGetBestCancelled(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	// cancelled before any move is searched, a1 is not legal
+	b := NewBoard(8)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, threads := range []int{1, 4} {
+		ap := NewAiPlayer(8)
+		ap.Threads = threads
+		ap.ctx = ctx
+
+		p, pv := ap.getBest(b)
+
+		assert.True(t, b.IsLegal(p.X+p.Y*b.W, b.Turn), "threads %d: %s", threads, p)
+		assert.Equal(t, p, pv[0].Position)
+	}
+}
@@ -0,0 +1,355 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+const (
+	DEFAULT_PATTERN_PHASES = 4
+	DEFAULT_PATTERN_EPOCHS = 20
+	DEFAULT_PATTERN_RATE   = 0.002
+
+	// L2 regularization of the pattern scores in training
+	patternDecay = 0.0001
+)
+
+// PatternWeights is the learned score tables of the lines for each board size.
+// It's saved as JSON like {"8": {"phases": 4, "tables": {"row1": [[...], ...], "diag5": ...}}}
+type PatternWeights map[int]*PatternTables
+
+// PatternTables has a score table for each line class and game phase.
+// tables[class][phase][Idx.Value] is the score of the line for black, in tenths of a disc
+type PatternTables struct {
+	Phases int                `json:"phases"`
+	Tables map[string][][]int `json:"tables"`
+}
+
+// aiPatterns replaces the cell scores with the pattern evaluation for the sizes it has. Loaded from pattern_file in the config
+var aiPatterns PatternWeights
+
+// lineClass is the group of lines that share a score table.
+// Rows and columns are grouped by the distance from the edge, and diagonals by their length
+type lineClass struct {
+	name   string
+	length int
+}
+
+// patternEvaluator scores a board with the line tables
+type patternEvaluator struct {
+	phases    int
+	lineClass []int     // class of each line id
+	tables    [][][]int // tables[class][phase][Idx.Value], nil if the file doesn't have the class
+}
+
+// lineClasses returns the classes of the board size, and the class of each line id
+func lineClasses(n int) ([]lineClass, []int) {
+	lengths := make(map[LineId]int)
+	for _, lineForCells := range NewLineForCells(n) {
+		for _, l := range lineForCells {
+			lengths[l.LineId]++
+		}
+	}
+
+	classes := make([]lineClass, 0)
+	ids := make(map[string]int)
+	lineN := len(lengths)
+	classOfLine := make([]int, lineN)
+
+	for line := 0; line < lineN; line++ {
+		var c lineClass
+		if line < 2*n {
+			i := line % n
+			c = lineClass{fmt.Sprintf("row%d", min(i, n-1-i)+1), n}
+		} else {
+			length := lengths[LineId(line)]
+			c = lineClass{fmt.Sprintf("diag%d", length), length}
+		}
+
+		id, ok := ids[c.name]
+		if !ok {
+			id = len(classes)
+			ids[c.name] = id
+			classes = append(classes, c)
+		}
+		classOfLine[line] = id
+	}
+
+	return classes, classOfLine
+}
+
+// reverseValue reads the first length cells of the line backwards
+func reverseValue(value int, length int) int {
+	reversed := 0
+	for i := 0; i < length; i++ {
+		reversed = reversed*3 + value%3
+		value /= 3
+	}
+	return reversed
+}
+
+// canonicalValues maps each value to the smaller of it and its reverse, so that a line and its mirror share a score
+func canonicalValues(length int) []int {
+	values := make([]int, pow(3, length))
+	for v := range values {
+		values[v] = min(v, reverseValue(v, length))
+	}
+	return values
+}
+
+func newPatternEvaluator(n int, pt *PatternTables) *patternEvaluator {
+	classes, classOfLine := lineClasses(n)
+
+	e := &patternEvaluator{
+		phases:    pt.Phases,
+		lineClass: classOfLine,
+		tables:    make([][][]int, len(classes)),
+	}
+
+	for i, c := range classes {
+		e.tables[i] = pt.Tables[c.name]
+	}
+
+	return e
+}
+
+// phaseOf returns the game phase by the number of discs
+func phaseOf(b *Board, phases int) int {
+	discs := b.CellN - b.CountEmptyCells()
+	return min((discs-4)*phases/(b.CellN-3), phases-1)
+}
+
+// score returns the score of the board for black
+func (e *patternEvaluator) score(b *Board) int {
+	phase := phaseOf(b, e.phases)
+
+	score := 0
+	for line, class := range e.lineClass {
+		table := e.tables[class]
+		if table == nil {
+			continue
+		}
+		score += table[phase][b.Lines[LineId(line)].Value]
+	}
+
+	return score
+}
+
+func LoadPatternWeights(path string) (PatternWeights, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read patterns: %w", err)
+	}
+
+	var patterns PatternWeights
+	if err := json.Unmarshal(data, &patterns); err != nil {
+		return nil, fmt.Errorf("Failed to parse patterns %s: %w", path, err)
+	}
+
+	if err := patterns.validate(); err != nil {
+		return nil, fmt.Errorf("Invalid patterns %s: %w", path, err)
+	}
+
+	return patterns, nil
+}
+
+func (p PatternWeights) validate() error {
+	for n, pt := range p {
+		if n < MIN_N || n > MAX_N {
+			return fmt.Errorf("board size must be between %d and %d: %d", MIN_N, MAX_N, n)
+		}
+
+		if pt == nil || pt.Phases < 1 {
+			return fmt.Errorf("%dx%d must have 1 or more phases", n, n)
+		}
+
+		classes, _ := lineClasses(n)
+		known := make(map[string]bool)
+
+		for _, c := range classes {
+			known[c.name] = true
+
+			table, ok := pt.Tables[c.name]
+			if !ok {
+				continue
+			}
+
+			if len(table) != pt.Phases {
+				return fmt.Errorf("%s of %dx%d must have %d phases", c.name, n, n, pt.Phases)
+			}
+
+			for _, scores := range table {
+				if len(scores) != pow(3, c.length) {
+					return fmt.Errorf("%s of %dx%d must have %d scores for each phase", c.name, n, n, pow(3, c.length))
+				}
+			}
+		}
+
+		for name := range pt.Tables {
+			if !known[name] {
+				return fmt.Errorf("%dx%d has no line class %q", n, n, name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p PatternWeights) Save(path string) error {
+	bytes, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("Failed to encode patterns: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(bytes, '\n'), 0644); err != nil {
+		return fmt.Errorf("Failed to save patterns: %w", err)
+	}
+
+	return nil
+}
+
+// loadPatternWeightsOrEmpty reads the patterns file if it exists, so that new tables can be added to it
+func loadPatternWeightsOrEmpty(path string) (PatternWeights, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return make(PatternWeights), nil
+	}
+
+	return LoadPatternWeights(path)
+}
+
+// PatternResult is the fitted line tables
+type PatternResult struct {
+	Tables  *PatternTables
+	Samples int
+	Solved  int
+	RMSE    float64 // error of the fitted evaluation, in discs
+}
+
+// patternSample is the table entries of every line of a position
+type patternSample struct {
+	phase  int
+	values []int // canonical value of each line
+	label  float64
+}
+
+// FitPatterns learns the line tables by stochastic gradient descent, so that the evaluation of a position is close to its label
+func (t Tuner) FitPatterns(samples []TuneSample) PatternResult {
+	classes, classOfLine := lineClasses(t.N)
+	phases := t.Phases
+
+	canonical := make([][]int, len(classes))
+	weights := make([][][]float64, len(classes))
+	for i, c := range classes {
+		canonical[i] = canonicalValues(c.length)
+		weights[i] = make([][]float64, phases)
+		for phase := range weights[i] {
+			weights[i][phase] = make([]float64, pow(3, c.length))
+		}
+	}
+
+	data := make([]patternSample, len(samples))
+	solved := 0
+
+	for k, s := range samples {
+		// the labels are for the player to move, and the tables are for black
+		label := s.Label
+		if s.Board.Turn == White {
+			label = -label
+		}
+
+		values := make([]int, len(classOfLine))
+		for line, class := range classOfLine {
+			values[line] = canonical[class][s.Board.Lines[LineId(line)].Value]
+		}
+
+		data[k] = patternSample{phaseOf(s.Board, phases), values, label}
+
+		if s.Solved {
+			solved++
+		}
+	}
+
+	predict := func(d patternSample) float64 {
+		p := 0.0
+		for line, class := range classOfLine {
+			p += weights[class][d.phase][d.values[line]]
+		}
+		return p
+	}
+
+	rng := rand.New(rand.NewSource(t.Seed))
+	order := rng.Perm(len(data))
+
+	for epoch := 0; epoch < t.Epochs; epoch++ {
+		rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+		for _, k := range order {
+			d := data[k]
+			diff := predict(d) - d.label
+
+			for line, class := range classOfLine {
+				w, v := weights[class][d.phase], d.values[line]
+				w[v] -= t.Rate * (diff + patternDecay*w[v])
+			}
+		}
+	}
+
+	sumSquares := 0.0
+	for _, d := range data {
+		sumSquares += math.Pow(predict(d)-d.label, 2)
+	}
+
+	pt := &PatternTables{Phases: phases, Tables: make(map[string][][]int)}
+
+	for i, c := range classes {
+		table := make([][]int, phases)
+		for phase := range table {
+			table[phase] = make([]int, pow(3, c.length))
+			for v := range table[phase] {
+				// every value has the score of its canonical value
+				table[phase][v] = int(math.Round(weights[i][phase][canonical[i][v]] * weightScale))
+			}
+		}
+		pt.Tables[c.name] = table
+	}
+
+	result := PatternResult{Tables: pt, Samples: len(samples), Solved: solved}
+	if len(data) > 0 {
+		result.RMSE = math.Sqrt(sumSquares / float64(len(data)))
+	}
+
+	return result
+}
+
+// WriteText writes how many patterns of each line class were learned
+func (r PatternResult) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "%d positions, %d solved to the end\n", r.Samples, r.Solved)
+	fmt.Fprintf(w, "RMSE %.2f discs\n\n", r.RMSE)
+
+	names := make([]string, 0, len(r.Tables.Tables))
+	for name := range r.Tables.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "%-6s %8s %8s\n", "Lines", "Patterns", "Learned")
+	for _, name := range names {
+		learned := 0
+		total := 0
+		for _, scores := range r.Tables.Tables[name] {
+			for _, score := range scores {
+				total++
+				if score != 0 {
+					learned++
+				}
+			}
+		}
+		fmt.Fprintf(w, "%-6s %8d %8d\n", name, total, learned)
+	}
+}
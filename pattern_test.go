@@ -0,0 +1,166 @@
+package main
+
+import (
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineClasses(t *testing.T) {
+	classes, classOfLine := lineClasses(8)
+
+	names := make([]string, 0, len(classes))
+	for _, c := range classes {
+		names = append(names, c.name)
+	}
+	assert.ElementsMatch(t, []string{"row1", "row2", "row3", "row4", "diag3", "diag4", "diag5", "diag6", "diag7", "diag8"}, names)
+
+	assert.Len(t, classOfLine, NewBoard(8).LineN)
+
+	// rows and columns on the edges share the table
+	assert.Equal(t, "row1", classes[classOfLine[0]].name)
+	assert.Equal(t, "row1", classes[classOfLine[7]].name)
+	assert.Equal(t, "row1", classes[classOfLine[8]].name)
+	assert.Equal(t, "row2", classes[classOfLine[14]].name)
+
+	// the first backslash diagonal starts on the 3rd lowest row
+	assert.Equal(t, lineClass{"diag3", 3}, classes[classOfLine[16]])
+}
+
+func TestCanonicalValues(t *testing.T) {
+	// X-- is 1, --X is 9
+	assert.Equal(t, 9, reverseValue(1, 3))
+	assert.Equal(t, 1, reverseValue(9, 3))
+
+	values := canonicalValues(3)
+	assert.Equal(t, 1, values[9])
+	assert.Equal(t, 1, values[1])
+	assert.Len(t, values, 27)
+}
+
+// discDiffTables scores every row and column by its black discs minus white discs
+func discDiffTables(n int, phases int) *PatternTables {
+	pt := &PatternTables{Phases: phases, Tables: make(map[string][][]int)}
+
+	classes, _ := lineClasses(n)
+	for _, c := range classes {
+		if c.length != n || c.name[:3] != "row" {
+			continue
+		}
+
+		table := make([][]int, phases)
+		for phase := range table {
+			table[phase] = make([]int, pow(3, n))
+			for v := range table[phase] {
+				idx := Idx{v, n}
+				for local := 0; local < n; local++ {
+					switch idx.GetLocalState(local) {
+					case HasBlack:
+						table[phase][v]++
+					case HasWhite:
+						table[phase][v]--
+					}
+				}
+			}
+		}
+		pt.Tables[c.name] = table
+	}
+
+	return pt
+}
+
+func TestPatternEvaluation(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	defer func() { aiPatterns = nil }()
+
+	aiPatterns = PatternWeights{6: discDiffTables(6, 2)}
+	ap := NewAiPlayer(6)
+
+	b := NewBoard(6)
+	assert.Equal(t, 0, ap.evaluate(b))
+
+	// black 4, white 1. rows and columns count each disc twice
+	b, _ = b.Place(Position{3, 1})
+	assert.Equal(t, -6, ap.evaluate(b))
+
+	// other sizes use the cell scores
+	assert.Nil(t, NewAiPlayer(8).patterns)
+}
+
+func TestPhaseOf(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	b := NewBoard(4)
+
+	assert.Equal(t, 0, phaseOf(b, 4))
+
+	full, err := parseBoardRows("XXXX/XXXX/OOOO/OOOO", Black)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, phaseOf(full, 4))
+}
+
+func TestPatternWeightsSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.json")
+	patterns := PatternWeights{4: discDiffTables(4, 2)}
+
+	assert.NoError(t, patterns.Save(path))
+
+	loaded, err := LoadPatternWeights(path)
+	assert.NoError(t, err)
+	assert.Equal(t, patterns, loaded)
+
+	e, err := ParseEngineConfig("depth=2,patterns=" + path)
+	assert.NoError(t, err)
+	assert.NotNil(t, e.newPlayer(4).(*AiPlayer).patterns)
+	assert.EqualError(t, e.validate(6), `Engine "depth=2,patterns=`+path+`": the patterns have no tables for 6x6`)
+}
+
+func TestLoadPatternWeightsInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	cases := map[string]string{
+		`{"4": {"phases": 0, "tables": {}}}`:              "4x4 must have 1 or more phases",
+		`{"4": {"phases": 1, "tables": {"row9": [[]]}}}`:  `4x4 has no line class "row9"`,
+		`{"4": {"phases": 2, "tables": {"row1": [[]]}}}`:  "row1 of 4x4 must have 2 phases",
+		`{"4": {"phases": 1, "tables": {"row1": [[1]]}}}`: "row1 of 4x4 must have 81 scores for each phase",
+		`{"11": {"phases": 1, "tables": {}}}`:             "board size must be between 3 and 10: 11",
+	}
+
+	for content, want := range cases {
+		path := filepath.Join(dir, "patterns.json")
+		os.WriteFile(path, []byte(content), 0644)
+
+		_, err := LoadPatternWeights(path)
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestTunerFitPatterns(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	tuner := Tuner{N: 6, Games: 20, Depth: 1, OpeningPlies: 4, SolveEmpties: 6, Seed: 1, Patterns: true, Phases: 2, Epochs: 10, Rate: DEFAULT_PATTERN_RATE}
+
+	samples, err := tuner.Samples(tuner.SelfPlay())
+	assert.NoError(t, err)
+
+	result := tuner.FitPatterns(samples)
+
+	assert.Equal(t, result, tuner.FitPatterns(samples))
+	assert.Equal(t, 2, result.Tables.Phases)
+	assert.NoError(t, PatternWeights{6: result.Tables}.validate())
+
+	// the fit is better than scoring every position 0
+	zero := 0.0
+	for _, s := range samples {
+		zero += s.Label * s.Label
+	}
+	assert.Less(t, result.RMSE, math.Sqrt(zero/float64(len(samples))))
+
+	// a line and its reverse have the same score
+	edge := result.Tables.Tables["row1"][0]
+	for v := range edge {
+		assert.Equal(t, edge[v], edge[reverseValue(v, 6)])
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const (
+	PipeBlack   = "X"
+	PipeWhite   = "O"
+	PipeNothing = "-"
+)
+
+// PipeDisplay writes machine-readable board dumps instead of drawing on the terminal
+//
+//	board <width> <height>
+//	<one line per row, X: black, O: white, -: empty>
+//	turn black|white
+//	state <GameState>
+//	score <black> <white>
+//	last <move>|none
+//	message <message>
+//	end
+type PipeDisplay struct {
+	out io.Writer
+	mu  *sync.Mutex
+}
+
+func NewPipeDisplay(out io.Writer) *PipeDisplay {
+	return &PipeDisplay{out: out, mu: &sync.Mutex{}}
+}
+
+func (d *PipeDisplay) Render(g *Game, p Position) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	fmt.Fprint(d.out, dumpGame(g))
+}
+
+func (d *PipeDisplay) Notify(message string) {
+	d.Respond("info " + message)
+}
+
+// Respond writes one response line
+func (d *PipeDisplay) Respond(line string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	fmt.Fprintln(d.out, line)
+}
+
+func (d *PipeDisplay) Close() {
+}
+
+func dumpGame(g *Game) string {
+	var builder strings.Builder
+
+	b := g.Board
+
+	fmt.Fprintf(&builder, "board %d %d\n", b.W, b.H)
+
+	for _, row := range boardRows(b) {
+		fmt.Fprintln(&builder, row)
+	}
+
+	turn := "black"
+	if b.Turn == White {
+		turn = "white"
+	}
+
+	last := "none"
+	if len(g.Moves) > 0 {
+		last = formatMove(g.Moves[len(g.Moves)-1])
+	}
+
+	totalB, totalW := b.Count()
+
+	fmt.Fprintf(&builder, "turn %s\n", turn)
+	fmt.Fprintf(&builder, "state %s\n", g.State)
+	fmt.Fprintf(&builder, "score %d %d\n", totalB, totalW)
+	fmt.Fprintf(&builder, "last %s\n", last)
+	fmt.Fprintf(&builder, "message %s\n", g.Message)
+	fmt.Fprintln(&builder, "end")
+
+	return builder.String()
+}
+
+// ReadLines sends each line of the reader to the channel, and closes it at EOF
+func ReadLines(r io.Reader, out chan<- string) {
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		out <- scanner.Text()
+	}
+
+	close(out)
+}
+
+// PipeSeat is a player controlled by the pipe
+type PipeSeat struct {
+	gameCh   <-chan Game
+	cmdCh    chan<- GameCommand
+	quitCh   chan<- bool
+	PlayerId PlayerId
+}
+
+func NewPipeSeat(
+	gameCh <-chan Game,
+	cmdCh chan<- GameCommand,
+	quitCh chan<- bool,
+	id PlayerId,
+) PipeSeat {
+	return PipeSeat{gameCh, cmdCh, quitCh, id}
+}
+
+// PipeClient controls one or more players with line commands:
+//
+//	move <coordinate>  place a disc, e.g., "move d3"
+//	pass               confirm the pass, the game passes automatically when there is no legal move
+//	undo               take back moves until it's your turn again
+//	show               dump the board
+//	quit               leave the game
+//
+// Commands are handled one by one. Each waits until it's the turn of one of the players,
+// and is answered by "ok ..." or "error ..." after the game has applied it.
+type PipeClient struct {
+	seats      []PipeSeat
+	inputCh    <-chan string
+	closeCliCh chan<- bool
+	d          *PipeDisplay
+	updateCh   chan<- Game // the games of the first seat, in order
+	gameCh     <-chan Game
+	g          Game // the latest game
+	seen       int  // the number of moves when a pass was last confirmed or a move was played
+}
+
+func NewPipeClient(
+	seats []PipeSeat,
+	inputCh <-chan string,
+	closeCliCh chan<- bool,
+	d *PipeDisplay,
+) PipeClient {
+	gameCh := make(chan Game)
+
+	return PipeClient{
+		seats:      seats,
+		inputCh:    inputCh,
+		closeCliCh: closeCliCh,
+		d:          d,
+		updateCh:   queueGames(gameCh),
+		gameCh:     gameCh,
+	}
+}
+
+func (c *PipeClient) Run() {
+	for i, seat := range c.seats {
+		go c.listen(seat, i == 0)
+	}
+
+	for line := range c.inputCh {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "quit" {
+			break
+		}
+
+		if !c.waitForTurn() {
+			c.d.Respond("error game is not in progress")
+			break
+		}
+
+		c.d.Respond(c.handle(fields))
+	}
+
+	// stdin is closed or quit is received
+	go func() { c.seats[0].quitCh <- true }()
+	c.d.Respond("ok quit")
+	c.closeCliCh <- true
+}
+
+// listen receives game updates for the seat. Only the first seat renders them and passes them to the client
+func (c *PipeClient) listen(seat PipeSeat, render bool) {
+	checked := false
+	last := ""
+
+	if render {
+		defer close(c.updateCh)
+	}
+
+	for g := range seat.gameCh {
+		if g.State == WaitingConnection && !g.GetPlayer(seat.PlayerId).Ready && !checked {
+			checked = true
+			go func() {
+				seat.cmdCh <- GameCommand{CommandType: CommandConnectionCheck}
+			}()
+		}
+
+		if render {
+			// the same board is dumped once, e.g., while the players connect
+			if dump := dumpGame(&g); dump != last {
+				last = dump
+				c.d.Render(&g, Position{})
+			}
+			c.updateCh <- g
+		}
+
+		if g.State == Quit {
+			return
+		}
+	}
+}
+
+// next waits for the next game update. It returns false once the game quits
+func (c *PipeClient) next() bool {
+	c.g = <-c.gameCh
+	return c.g.State != Quit
+}
+
+// waitForTurn waits until one of the players can send a command: it's their turn or the game is finished.
+// It returns false if the game quits
+func (c *PipeClient) waitForTurn() bool {
+	for {
+		switch c.g.State {
+		case Finished:
+			return true
+		case Player1Turn, Player2Turn:
+			if _, err := c.seatToMove(); err == nil {
+				return true
+			}
+		}
+
+		if !c.next() {
+			return false
+		}
+	}
+}
+
+func (c *PipeClient) handle(fields []string) string {
+	g := &c.g
+
+	switch fields[0] {
+	case "show":
+		c.d.Render(g, Position{})
+		return "ok show"
+
+	case "move":
+		if len(fields) != 2 {
+			return "error usage: move <coordinate>"
+		}
+
+		seat, err := c.seatToMove()
+		if err != nil {
+			return fmt.Sprintf("error %s", err)
+		}
+
+		p, err := parseCoordinate(fields[1])
+		if err != nil {
+			return fmt.Sprintf("error %s", err)
+		}
+
+		if p.X >= g.Board.W || p.Y >= g.Board.H || !g.Board.IsLegal(p.X+p.Y*g.Board.W, g.Board.Turn) {
+			return fmt.Sprintf("error illegal move %s", p)
+		}
+
+		moveN := len(g.Moves)
+
+		seat.cmdCh <- GameCommand{CommandType: CommandPlace, Position: p}
+		if !c.next() {
+			return "error game is not in progress"
+		}
+
+		if len(c.g.Moves) <= moveN {
+			return fmt.Sprintf("error %s", c.g.Message)
+		}
+
+		c.seen = moveN + 1
+		return fmt.Sprintf("ok move %s", p)
+
+	case "pass":
+		// the game has passed for the player already
+		if c.skipped() {
+			c.seen = len(g.Moves)
+			return "ok pass"
+		}
+
+		if _, err := c.seatToMove(); err != nil {
+			return fmt.Sprintf("error %s", err)
+		}
+
+		return "error pass is not allowed, there are legal moves"
+
+	case "undo":
+		seat, err := c.seatToMove()
+		if err != nil && g.State != Finished {
+			return fmt.Sprintf("error %s", err)
+		}
+		if err != nil {
+			seat = c.seats[0]
+		}
+
+		seat.cmdCh <- GameCommand{CommandType: CommandUndo}
+		if !c.next() {
+			return "error game is not in progress"
+		}
+
+		if c.g.Message == messageNoUndo {
+			return fmt.Sprintf("error %s", messageNoUndo)
+		}
+
+		c.seen = min(c.seen, len(c.g.Moves))
+		return "ok undo"
+	}
+
+	return fmt.Sprintf("error unknown command %q", fields[0])
+}
+
+// skipped tells that the game has passed for one of the players since the last pass or move of the pipe
+func (c *PipeClient) skipped() bool {
+	for _, m := range c.g.Moves[min(c.seen, len(c.g.Moves)):] {
+		if !m.Pass {
+			continue
+		}
+
+		for _, seat := range c.seats {
+			if c.g.GetPlayer(seat.PlayerId).Colour == m.Colour {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// seatToMove returns the seat whose turn it is
+func (c *PipeClient) seatToMove() (PipeSeat, error) {
+	if c.g.State != Player1Turn && c.g.State != Player2Turn {
+		return PipeSeat{}, fmt.Errorf("game is not in progress")
+	}
+
+	for _, seat := range c.seats {
+		if c.g.IsMyTurn(seat.PlayerId) {
+			return seat, nil
+		}
+	}
+
+	return PipeSeat{}, fmt.Errorf("not your turn")
+}
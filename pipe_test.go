@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// safeBuffer is a bytes.Buffer which can be written and read from different goroutines
+type safeBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestPipeDumpGame(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	g := NewGame(NewBoard(4), Human, Human)
+	g.State = Player1Turn
+	g.Message = "hello"
+
+	want := strings.Join([]string{
+		"board 4 4",
+		"----",
+		"-XO-",
+		"-OX-",
+		"----",
+		"turn black",
+		"state Player1Turn",
+		"score 2 2",
+		"last none",
+		"message hello",
+		"end",
+		"",
+	}, "\n")
+
+	assert.Equal(t, want, dumpGame(&g))
+}
+
+func TestPipeClientPlaysAndUndoes(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	g := NewGame(NewBoard(4), Human, Human)
+
+	player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, player1QuitCh, player2QuitCh := g.Start()
+
+	seats := []PipeSeat{
+		NewPipeSeat(player1GameCh, player1CmdCh, player1QuitCh, Player1Id),
+		NewPipeSeat(player2GameCh, player2CmdCh, player2QuitCh, Player2Id),
+	}
+
+	out := &safeBuffer{}
+	inputCh := make(chan string)
+	closeCh := make(chan bool)
+
+	cli := NewPipeClient(seats, inputCh, closeCh, NewPipeDisplay(out))
+	go cli.Run()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, Player1Turn, g.State)
+
+	inputCh <- "move a1"
+	inputCh <- "move c1"
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, Player2Turn, g.State)
+
+	inputCh <- "pass"
+	inputCh <- "move b1"
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, Player1Turn, g.State)
+
+	inputCh <- "undo"
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, Player1Turn, g.State)
+	assert.Equal(t, 0, len(g.Moves))
+
+	inputCh <- "dance"
+	inputCh <- "quit"
+	<-closeCh
+
+	got := out.String()
+	assert.Contains(t, got, "error illegal move a1\n")
+	assert.Contains(t, got, "ok move c1\n")
+	assert.Contains(t, got, "last c1\n")
+	assert.Contains(t, got, "error pass is not allowed, there are legal moves\n")
+	assert.Contains(t, got, "ok undo\n")
+	assert.Contains(t, got, "error unknown command \"dance\"\n")
+	assert.Contains(t, got, "ok quit\n")
+}
+
+func TestPipeClientHandlesCommandsInOrder(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	g := NewGame(NewBoard(8), Human, Human)
+
+	player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, player1QuitCh, player2QuitCh := g.Start()
+
+	seats := []PipeSeat{
+		NewPipeSeat(player1GameCh, player1CmdCh, player1QuitCh, Player1Id),
+		NewPipeSeat(player2GameCh, player2CmdCh, player2QuitCh, Player2Id),
+	}
+
+	// every command arrives before the game starts
+	commands := []string{"move e3", "move d3", "pass", "undo", "undo", "quit"}
+	inputCh := make(chan string, len(commands))
+	for _, command := range commands {
+		inputCh <- command
+	}
+
+	out := &safeBuffer{}
+	closeCh := make(chan bool)
+
+	cli := NewPipeClient(seats, inputCh, closeCh, NewPipeDisplay(out))
+	go cli.Run()
+
+	select {
+	case <-closeCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pipe client didn't finish the commands")
+	}
+
+	responses := make([]string, 0)
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(line, "ok") || strings.HasPrefix(line, "error") {
+			responses = append(responses, line)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"ok move e3",
+		"ok move d3",
+		"error pass is not allowed, there are legal moves",
+		"ok undo",
+		"error " + messageNoUndo,
+		"ok quit",
+	}, responses)
+
+	// the board is dumped once for each change
+	assert.Equal(t, 1, strings.Count(out.String(), "last d3\n"))
+}
+
+func TestPipeClientConfirmsPass(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	cli := NewPipeClient([]PipeSeat{{PlayerId: Player1Id}}, nil, nil, NewPipeDisplay(&safeBuffer{}))
+
+	cli.g = NewGame(NewBoard(4), Human, AI)
+	cli.g.State = Player2Turn
+	cli.g.Moves = []Move{
+		{Colour: White, Position: Position{0, 0}},
+		{Colour: Black, Pass: true},
+	}
+
+	assert.Equal(t, "ok pass", cli.handle([]string{"pass"}))
+	assert.Equal(t, "error not your turn", cli.handle([]string{"pass"}))
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Ponderer is an engine whose search stops when the context is cancelled, so that it can think during the opponent's turn
+type Ponderer interface {
+	Engine
+	getPositionContext(ctx context.Context, b *Board) (Position, error)
+}
+
+// getPositionContext is getPosition that stops with the error of the context when it's cancelled
+func (ap *AiPlayer) getPositionContext(ctx context.Context, b *Board) (Position, error) {
+	ap.ctx = ctx
+	defer func() { ap.ctx = nil }()
+
+	p := ap.getPosition(b)
+
+	return p, ctx.Err()
+}
+
+// cancelled tells the search to stop. The score of a stopped search is meaningless
+func (ap *AiPlayer) cancelled() bool {
+	return ap.ctx != nil && ap.ctx.Err() != nil
+}
+
+func (mp *MctsPlayer) getPositionContext(ctx context.Context, b *Board) (Position, error) {
+	mp.ctx = ctx
+	defer func() { mp.ctx = nil }()
+
+	p := mp.getPosition(b)
+
+	return p, ctx.Err()
+}
+
+// startPondering searches the replies to each move of the opponent in the background, one at a time.
+// The symmetrical boards after the opponent's moves are searched only once.
+// The engine is not used by anything else until stopPondering
+func (c *AiClient) startPondering(b *Board) {
+	engine, ok := c.p.(Ponderer)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	c.ponderKey = positionKey(b)
+	c.ponderCancel = cancel
+	c.ponderDone = done
+	c.ponderCache = make(map[string]Position)
+
+	cache := c.ponderCache
+	b = b.CopyBoard()
+
+	go func() {
+		defer close(done)
+
+		// the opponent's likely moves first
+		for _, m := range likelyMoves(b) {
+			reply := m.place(b)
+			if !reply.HasLegalMove(reply.Turn) {
+				continue
+			}
+
+			// the moves are kept on the canonical board, so that a symmetrical board finds them
+			key, s := canonical(reply)
+			if _, ok := cache[key]; ok {
+				continue
+			}
+
+			p, err := engine.getPositionContext(ctx, reply)
+			if err != nil {
+				return
+			}
+
+			cache[key] = s.Position(p, reply.W, reply.H)
+		}
+
+		logger.Debug("pondered", slog.Int("replies", len(cache)))
+	}()
+}
+
+// stopPondering cancels the background search and waits for it
+func (c *AiClient) stopPondering() {
+	if c.ponderCancel == nil {
+		return
+	}
+
+	c.ponderCancel()
+	<-c.ponderDone
+
+	c.ponderCancel = nil
+	c.ponderKey = ""
+}
+
+// pondered returns the move found during the opponent's turn for the board, if any
+func (c *AiClient) pondered(b *Board) (Position, bool) {
+	key, s := canonical(b)
+	p, ok := c.ponderCache[key]
+	c.ponderCache = nil
+
+	if ok {
+		// back from the canonical board
+		w, h := s.Size(b.W, b.H)
+		p = s.Inverse().Position(p, w, h)
+
+		c.ponderHits++
+		logger.Debug("ponder hit", slog.Any("position", p))
+	}
+
+	return p, ok
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPositionContextCancelled(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ap := NewAiPlayer(8)
+	ap.depth = 12
+
+	start := time.Now()
+	_, err := ap.getPositionContext(ctx, NewBoard(8))
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Nil(t, ap.ctx)
+
+	mp := NewMctsPlayer(8)
+	mp.TimeLimit = time.Minute
+
+	_, err = mp.getPositionContext(ctx, NewBoard(8))
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, mp.iterations)
+}
+
+func TestGetPositionContext(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(6)
+	ap := NewAiPlayerWithLevel(6, 3)
+
+	p, err := ap.getPositionContext(context.Background(), b)
+
+	assert.NoError(t, err)
+	assert.Equal(t, NewAiPlayerWithLevel(6, 3).getPosition(b), p)
+}
+
+func TestAiClientPondering(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	c := NewAiClient(6, 3, nil, nil, nil, Player2Id)
+
+	// black is the opponent
+	b := NewBoard(6)
+	c.startPondering(b)
+	<-c.ponderDone
+	c.stopPondering()
+
+	// the boards after the first moves are all symmetrical, and searched once
+	assert.Len(t, c.ponderCache, 1)
+	want := ponderedKey(b)
+
+	for _, m := range legalPositions(b) {
+		cache := c.ponderCache
+
+		reply, _ := b.Place(m)
+		p, ok := c.pondered(reply)
+		assert.True(t, ok)
+
+		// the same move seen from the side of the reply
+		placed, err := reply.Place(p)
+		assert.NoError(t, err)
+		assert.Equal(t, want, canonicalKey(placed))
+
+		c.ponderCache = cache
+	}
+	assert.Equal(t, 4, c.ponderHits)
+
+	// the cache is only for one turn
+	reply, _ := b.Place(legalPositions(b)[0])
+	c.pondered(reply)
+	_, ok := c.pondered(reply)
+	assert.False(t, ok)
+}
+
+// ponderedKey returns the canonicalKey of the board after the AI's reply to the opponent's likeliest move
+func ponderedKey(b *Board) string {
+	reply := likelyMoves(b)[0].place(b)
+	placed, _ := reply.Place(NewAiPlayerWithLevel(b.N, 3).getPosition(reply))
+	return canonicalKey(placed)
+}
+
+func TestAiClientPonderRun(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	gameCh := make(chan Game)
+	cmdCh := make(chan GameCommand)
+
+	c := NewAiClient(6, 3, gameCh, cmdCh, nil, Player2Id)
+	c.MinDelay = 0
+	c.Ponder = true
+
+	done := make(chan bool)
+	go func() {
+		c.Run()
+		done <- true
+	}()
+
+	g := NewGame(NewBoard(6), Human, AI)
+	g.State = Player1Turn
+	gameCh <- g
+
+	// the same board again doesn't restart the pondering
+	gameCh <- g
+	time.Sleep(300 * time.Millisecond)
+
+	start := g.Board
+	reply, _ := start.Place(legalPositions(start)[1])
+	g.Board = reply
+	g.State = Player2Turn
+	gameCh <- g
+
+	cmd := <-cmdCh
+	assert.Equal(t, CommandPlace, cmd.CommandType)
+
+	placed, err := reply.Place(cmd.Position)
+	assert.NoError(t, err)
+	assert.Equal(t, ponderedKey(start), canonicalKey(placed))
+
+	g.State = Quit
+	gameCh <- g
+	<-done
+
+	assert.Equal(t, 1, c.ponderHits)
+	assert.Nil(t, c.ponderCancel)
+}
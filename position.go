@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// the size of the position notation without the size
+	positionDefaultN = 8
+
+	messagePosition = "📋  %s"
+)
+
+// FormatPosition returns the board in the position notation: the cells row by row in X, O, - and # (a hole),
+// and the player to move, X or O. The size comes first unless the board is 8x8, e.g.,
+//
+//	---------------------------XO------OX--------------------------- X
+//	4x4 -----XO--OX----- X
+func FormatPosition(b *Board) string {
+	s := strings.Join(boardRows(b), "") + " " + positionTurn(b.Turn)
+
+	if b.W != positionDefaultN || b.H != positionDefaultN {
+		s = fmt.Sprintf("%dx%d %s", b.W, b.H, s)
+	}
+
+	return s
+}
+
+// ParsePosition returns the board of the position notation. The position is kept in the variant,
+// so that the game starts from it again and the record is replayed from it
+func ParsePosition(s string) (*Board, error) {
+	rows, turn, err := splitPosition(s)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := parseBoardRows(strings.Join(rows, "/"), turn)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid position: %w", err)
+	}
+
+	// the position is instead of the start of the opening
+	b.Variant.Start = ""
+	b.Variant.Position = FormatPosition(b)
+
+	return b, nil
+}
+
+// parseStartPosition parses the position a game starts from. The player to move must be able to place
+func parseStartPosition(s string) (*Board, error) {
+	b, err := ParsePosition(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if !b.HasLegalMove(b.Turn) {
+		return nil, fmt.Errorf("%s can't place in the position %q", colourName(b.Turn), s)
+	}
+
+	return b, nil
+}
+
+// splitPosition returns the rows and the player to move of the position notation
+func splitPosition(s string) ([]string, Turn, error) {
+	fields := strings.Fields(s)
+
+	w, h := positionDefaultN, positionDefaultN
+
+	switch len(fields) {
+	case 2:
+	case 3:
+		var ok bool
+		w, h, ok = parseBoardSize(fields[0])
+		if !ok {
+			return nil, Black, fmt.Errorf("Invalid position size %q: it must be like 6x6", fields[0])
+		}
+		fields = fields[1:]
+	default:
+		return nil, Black, fmt.Errorf("Invalid position %q: it must be the cells and X or O to move, with the size unless 8x8", s)
+	}
+
+	cells, side := fields[0], fields[1]
+
+	turn, err := parseColour(side)
+	if err != nil {
+		return nil, Black, fmt.Errorf("Invalid player to move %q: it must be X or O", side)
+	}
+
+	if w < MIN_N || w > MAX_N || h < MIN_N || h > MAX_N {
+		return nil, Black, fmt.Errorf("Invalid position size %dx%d: each side must be between %d and %d", w, h, MIN_N, MAX_N)
+	}
+
+	if len(cells) != w*h {
+		return nil, Black, fmt.Errorf("Position of %dx%d must have %d cells: %d", w, h, w*h, len(cells))
+	}
+
+	rows := make([]string, h)
+	for y := range rows {
+		rows[y] = cells[y*w : (y+1)*w]
+	}
+
+	return rows, turn, nil
+}
+
+func positionTurn(t Turn) string {
+	if t == Black {
+		return PipeBlack
+	}
+	return PipeWhite
+}
+
+// initPosition places the discs of the position in the variant, which must be valid
+func (b *Board) initPosition() {
+	rows, turn, _ := splitPosition(b.Variant.Position)
+
+	cells := make([][]string, len(rows))
+	for y, row := range rows {
+		cells[y] = make([]string, len(row))
+		for x, c := range strings.ToUpper(row) {
+			switch string(c) {
+			case PipeBlack:
+				cells[y][x] = HasBlack.String()
+			case PipeWhite:
+				cells[y][x] = HasWhite.String()
+			default:
+				cells[y][x] = HasNothing.String()
+			}
+		}
+	}
+
+	b.FromStringCells(cells)
+	b.Turn = turn
+}
+
+// validatePosition checks the position of the variant read from a record for the board of w x h
+func (v Variant) validatePosition(w, h int) error {
+	if v.Opening != "" && v.Opening != OpeningStandard {
+		return fmt.Errorf("The %s opening can't start from a position", v.Opening)
+	}
+
+	if v.Start != "" {
+		return fmt.Errorf("A position has no start: %q", v.Start)
+	}
+
+	b, err := ParsePosition(v.Position)
+	if err != nil {
+		return err
+	}
+
+	if b.W != w || b.H != h {
+		return fmt.Errorf("Position is %dx%d, not %dx%d", b.W, b.H, w, h)
+	}
+
+	if strings.Join(b.Variant.Holes, " ") != strings.Join(v.Holes, " ") {
+		return fmt.Errorf("Holes %v are not the holes of the position %v", v.Holes, b.Variant.Holes)
+	}
+
+	return nil
+}
+
+// showPosition shows the position of the board on the message line to copy it
+func showPosition(b *Board, d Renderer) {
+	if b == nil {
+		return
+	}
+
+	d.Notify(fmt.Sprintf(messagePosition, FormatPosition(b)))
+}
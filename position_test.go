@@ -0,0 +1,118 @@
+package main
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatPosition(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	assert.Equal(t, "---------------------------XO------OX--------------------------- X", FormatPosition(NewBoard(8)))
+
+	b, _ := NewBoard(4).Place(Position{2, 0})
+	assert.Equal(t, "4x4 --X--XX--OX----- O", FormatPosition(b))
+
+	rect := NewRectBoard(5, 3, Variant{Holes: []string{"a1", "e3"}})
+	assert.Equal(t, "5x3 #XO---OX------# X", FormatPosition(rect))
+}
+
+func TestParsePosition(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	for _, s := range []string{
+		"---------------------------XO------OX--------------------------- X",
+		"4x4 --X--XX--OX----- O",
+		"5x3 #XO---OX------# X",
+		"10x6 ------------------------XO--------OX------------------------ O",
+	} {
+		b, err := ParsePosition(s)
+		if !assert.NoError(t, err, s) {
+			continue
+		}
+		assert.Equal(t, s, FormatPosition(b))
+		assert.Equal(t, s, b.Variant.Position)
+	}
+
+	b, err := ParsePosition("4x4 --x--xx--ox----- white")
+	assert.NoError(t, err)
+	assert.Equal(t, White, b.Turn)
+	assert.Equal(t, []string{"--X-", "-XX-", "-OX-", "----"}, boardRows(b))
+
+	holes, _ := ParsePosition("5x3 #XO---OX------# X")
+	assert.Equal(t, []string{"a1", "e3"}, holes.Variant.Holes)
+	assert.True(t, holes.IsHole(Position{4, 2}))
+
+	for s, msg := range map[string]string{
+		"-----XO--OX----- X":       "Position of 8x8 must have 64 cells: 16",
+		"4x4 -----XO--OX----- Z":   `Invalid player to move "Z": it must be X or O`,
+		"4by4 -----XO--OX----- X":  `Invalid position size "4by4": it must be like 6x6`,
+		"12x4 -----XO--OX----- X":  "Invalid position size 12x4: each side must be between 3 and 10",
+		"4x4 -----XO--OZ----- X":   `Invalid position: Row 3 has an unknown cell 'Z'`,
+		"4x4 -----XO--OX-----":     `Invalid player to move "-----XO--OX-----": it must be X or O`,
+		"4x4 -----XO--OX----- X X": `Invalid position "4x4 -----XO--OX----- X X": it must be the cells and X or O to move, with the size unless 8x8`,
+	} {
+		_, err := ParsePosition(s)
+		assert.EqualError(t, err, msg, s)
+	}
+}
+
+func TestGameFromPosition(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	pb, err := parseStartPosition("4x4 --X--XX--OX----- O")
+	assert.NoError(t, err)
+
+	b := NewRectBoard(pb.W, pb.H, pb.Variant)
+	assert.Equal(t, White, b.Turn)
+	assert.Equal(t, boardRows(pb), boardRows(b))
+
+	g := NewGame(b, Human, Human)
+	assert.Equal(t, "💫  Game Start! (custom position)", g.startMessage())
+	g.updateTurnFromBoard()
+	assert.Equal(t, Player2Turn, g.State)
+
+	g.place(Position{1, 0})
+
+	// the record is replayed from the position
+	r := g.Record()
+	assert.Equal(t, pb.Variant.Position, r.Position)
+
+	boards, _, err := r.Positions()
+	assert.NoError(t, err)
+	assert.Equal(t, boardRows(g.Board), boardRows(boards[1]))
+
+	// the next game starts from the position again
+	g.replay()
+	assert.Equal(t, boardRows(pb), boardRows(g.Board))
+	assert.Equal(t, White, g.Board.Turn)
+
+	_, err = parseStartPosition("3x3 XXX/XOX/XXX O")
+	assert.Error(t, err)
+
+	_, err = parseStartPosition("3x3 XXXXO-XXX O")
+	assert.EqualError(t, err, `White can't place in the position "3x3 XXXXO-XXX O"`)
+}
+
+func TestRecordPositionVariant(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	r := Record{N: 4, Variant: Variant{Position: "4x4 --X--XX--OX----- O"}, Moves: []string{"b1"}}
+	boards, _, err := r.Positions()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"-OX-", "-OX-", "-OX-", "----"}, boardRows(boards[1]))
+
+	r.N = 6
+	_, _, err = r.Positions()
+	assert.EqualError(t, err, "Invalid variant: Position is 4x4, not 6x6")
+
+	r = Record{N: 4, Variant: Variant{Opening: OpeningFree, Position: "4x4 --X--XX--OX----- O"}}
+	_, _, err = r.Positions()
+	assert.EqualError(t, err, "Invalid variant: The free opening can't start from a position")
+
+	r = Record{N: 5, Width: 5, Height: 3, Variant: Variant{Position: "5x3 #XO---OX------# X"}}
+	_, _, err = r.Positions()
+	assert.EqualError(t, err, "Invalid variant: Holes [] are not the holes of the position [a1 e3]")
+}
@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Record is a saved game. It's saved as JSON, and can also be read from a plain move list:
+//
+//	# comment
+//	6x6            (optional board size, 8x8 if omitted. 10x6 is 10 wide and 6 high)
+//	f5 d6 c3 -- d3 (-- is a pass)
+type Record struct {
+	N          int      `json:"n"`                // the longer side on a rectangular board
+	Width      int      `json:"width,omitempty"`  // only on a rectangular board
+	Height     int      `json:"height,omitempty"` // only on a rectangular board
+	Black      string   `json:"black,omitempty"`
+	White      string   `json:"white,omitempty"`
+	BlackHints int      `json:"black_hints,omitempty"` // hints the player asked for
+	WhiteHints int      `json:"white_hints,omitempty"`
+	Moves      []string `json:"moves"`
+
+	Variant // the standard rules if it's empty
+}
+
+// Record returns the record of the moves played so far
+func (g *Game) Record() Record {
+	r := Record{N: g.Board.N, Moves: make([]string, 0, len(g.Moves))}
+
+	if g.Board.W != g.Board.H {
+		r.Width, r.Height = g.Board.W, g.Board.H
+	}
+
+	if !g.Board.Variant.isStandard() {
+		r.Variant = g.Board.Variant
+	}
+
+	if g.Player1.Colour == Black {
+		r.Black, r.White = g.Player1.Name, g.Player2.Name
+		r.BlackHints, r.WhiteHints = g.Player1.Hints, g.Player2.Hints
+	} else {
+		r.Black, r.White = g.Player2.Name, g.Player1.Name
+		r.BlackHints, r.WhiteHints = g.Player2.Hints, g.Player1.Hints
+	}
+
+	for _, m := range g.Moves {
+		r.Moves = append(r.Moves, formatMove(m))
+	}
+
+	return r
+}
+
+// sides returns the width and the height of the board
+func (r Record) sides() (int, int) {
+	if r.Width != 0 || r.Height != 0 {
+		return r.Width, r.Height
+	}
+	return r.N, r.N
+}
+
+func LoadRecord(path string) (Record, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return Record{}, fmt.Errorf("Failed to read record: %w", err)
+	}
+
+	return ParseRecord(bytes)
+}
+
+// ParseRecord reads a record in JSON or a plain move list
+func ParseRecord(data []byte) (Record, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var r Record
+		if err := json.Unmarshal(data, &r); err != nil {
+			return Record{}, fmt.Errorf("Failed to parse record: %w", err)
+		}
+		if r.N == 0 {
+			r.N = DEFAULT_N
+		}
+		return r, nil
+	}
+
+	r := Record{N: DEFAULT_N, Moves: make([]string, 0)}
+
+	for i, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		for _, token := range strings.Fields(line) {
+			if w, h, ok := parseBoardSize(token); ok && len(r.Moves) == 0 {
+				r.N = max(w, h)
+				if w != h {
+					r.Width, r.Height = w, h
+				}
+				continue
+			}
+
+			if token != PassString {
+				if _, err := parseCoordinate(token); err != nil {
+					return Record{}, fmt.Errorf("Failed to parse record on line %d: %w", i+1, err)
+				}
+			}
+
+			r.Moves = append(r.Moves, strings.ToLower(token))
+		}
+	}
+
+	return r, nil
+}
+
+// parseBoardSize parses the size like "6x6", or "10x6" for the width and the height
+func parseBoardSize(s string) (int, int, bool) {
+	ws, hs, ok := strings.Cut(s, "x")
+	if !ok {
+		return 0, 0, false
+	}
+
+	w, err := strconv.Atoi(ws)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	h, err := strconv.Atoi(hs)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return w, h, true
+}
+
+func (r Record) Save(path string) error {
+	bytes, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Failed to encode record: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(bytes, '\n'), 0644); err != nil {
+		return fmt.Errorf("Failed to save record: %w", err)
+	}
+
+	return nil
+}
+
+// Positions replays the record from the initial board. boards[i] is the board after moves[:i].
+// A pass is added if the player has no legal move and the record doesn't have it.
+// On an illegal move, it returns the positions before the move with the error
+func (r Record) Positions() ([]*Board, []Move, error) {
+	w, h := r.sides()
+
+	if w == h && (r.N < MIN_N || r.N > MAX_N) {
+		return nil, nil, fmt.Errorf("Board size must be between %d and %d: %d", MIN_N, MAX_N, r.N)
+	}
+
+	if w != h && (w < MIN_N || w > MAX_N || h < MIN_N || h > MAX_N) {
+		return nil, nil, fmt.Errorf("Board sides must be between %d and %d: %dx%d", MIN_N, MAX_N, w, h)
+	}
+
+	if err := r.Variant.validate(w, h); err != nil {
+		return nil, nil, fmt.Errorf("Invalid variant: %w", err)
+	}
+
+	b := NewRectBoard(w, h, r.Variant)
+
+	boards := []*Board{b}
+	moves := make([]Move, 0, len(r.Moves))
+
+	pass := func() {
+		b = b.CopyBoard()
+		b.SwitchTurn()
+
+		totalB, totalW := b.Count()
+		moves = append(moves, Move{Colour: !b.Turn, Pass: true, Black: totalB, White: totalW})
+		boards = append(boards, b)
+	}
+
+	for i, token := range r.Moves {
+		finished := !b.HasLegalMove(Black) && !b.HasLegalMove(White)
+		if finished {
+			return boards, moves, fmt.Errorf("Move %d (%s) is illegal: the game is already finished", i+1, token)
+		}
+
+		if token == PassString {
+			if b.HasLegalMove(b.Turn) {
+				return boards, moves, fmt.Errorf("Move %d (%s) is illegal: %s has a legal move", i+1, token, colourName(b.Turn))
+			}
+			pass()
+			continue
+		}
+
+		if !b.HasLegalMove(b.Turn) {
+			pass()
+		}
+
+		p, err := parseCoordinate(token)
+		if err != nil {
+			return boards, moves, fmt.Errorf("Move %d (%s) is illegal: %w", i+1, token, err)
+		}
+
+		if p.X >= w || p.Y >= h {
+			return boards, moves, fmt.Errorf("Move %d (%s) is illegal: out of the board", i+1, token)
+		}
+
+		placed, err := b.Place(p)
+		if err != nil {
+			return boards, moves, fmt.Errorf("Move %d (%s) is illegal: %w", i+1, token, err)
+		}
+		b = placed
+
+		totalB, totalW := b.Count()
+		moves = append(moves, Move{Colour: !b.Turn, Position: p, Black: totalB, White: totalW})
+		boards = append(boards, b)
+	}
+
+	return boards, moves, nil
+}
+
+// boardRows returns the rows of the board in X, O and -
+func boardRows(b *Board) []string {
+	rows := make([]string, 0, b.H)
+
+	for y := 0; y < b.H; y++ {
+		var builder strings.Builder
+
+		idx := b.Lines[LineId(y)]
+		for x := 0; x < b.W; x++ {
+			if b.IsHole(Position{x, y}) {
+				builder.WriteString(HoleMark)
+				continue
+			}
+
+			switch idx.GetLocalState(x) {
+			case HasBlack:
+				builder.WriteString(PipeBlack)
+			case HasWhite:
+				builder.WriteString(PipeWhite)
+			default:
+				builder.WriteString(PipeNothing)
+			}
+		}
+
+		rows = append(rows, builder.String())
+	}
+
+	return rows
+}
+
+// parseBoardRows makes a board from rows in X, O, - and # (a hole), separated by "/" or spaces.
+// Every row must have the same number of cells, which can differ from the number of rows
+func parseBoardRows(s string, turn Turn) (*Board, error) {
+	rows := strings.FieldsFunc(s, func(r rune) bool { return r == '/' || r == ' ' || r == '\n' })
+
+	h := len(rows)
+	if h < MIN_N || h > MAX_N {
+		return nil, fmt.Errorf("Board must have %d to %d rows: %d", MIN_N, MAX_N, h)
+	}
+
+	w := len(rows[0])
+	if w < MIN_N || w > MAX_N {
+		return nil, fmt.Errorf("Row 1 must have %d to %d cells: %q", MIN_N, MAX_N, rows[0])
+	}
+
+	cells := make([][]string, h)
+	holes := make([]string, 0)
+
+	for y, row := range rows {
+		if len(row) != w {
+			return nil, fmt.Errorf("Row %d must have %d cells: %q", y+1, w, row)
+		}
+
+		cells[y] = make([]string, w)
+		for x, c := range strings.ToUpper(row) {
+			switch string(c) {
+			case PipeBlack:
+				cells[y][x] = HasBlack.String()
+			case PipeWhite:
+				cells[y][x] = HasWhite.String()
+			case PipeNothing:
+				cells[y][x] = HasNothing.String()
+			case HoleMark:
+				cells[y][x] = HasNothing.String()
+				holes = append(holes, Position{x, y}.String())
+			default:
+				return nil, fmt.Errorf("Row %d has an unknown cell %q", y+1, c)
+			}
+		}
+	}
+
+	b := NewRectBoard(w, h, Variant{Holes: holes})
+	b.FromStringCells(cells)
+	b.Turn = turn
+
+	return b, nil
+}
+
+// GameRecorder passes the renders to the display, and keeps the last game to save its record
+type GameRecorder struct {
+	Renderer
+	mu *sync.Mutex
+	g  *Game
+}
+
+func NewGameRecorder(d Renderer) *GameRecorder {
+	return &GameRecorder{Renderer: d, mu: &sync.Mutex{}}
+}
+
+func (r *GameRecorder) Render(g *Game, p Position) {
+	r.mu.Lock()
+	copied := *g
+	r.g = &copied
+	r.mu.Unlock()
+
+	r.Renderer.Render(g, p)
+}
+
+// Save writes the record of the last game, if any move was played
+func (r *GameRecorder) Save(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.g == nil || len(r.g.Moves) == 0 {
+		return nil
+	}
+
+	return r.g.Record().Save(path)
+}
+
+// recordPosition returns the board after moveN moves of the record, or the last board if moveN is negative
+func recordPosition(path string, moveN int) (*Board, error) {
+	r, err := LoadRecord(path)
+	if err != nil {
+		return nil, err
+	}
+
+	boards, _, err := r.Positions()
+	if err != nil {
+		return nil, err
+	}
+
+	if moveN < 0 {
+		return boards[len(boards)-1], nil
+	}
+
+	if moveN >= len(boards) {
+		return nil, fmt.Errorf("The record has only %d moves", len(boards)-1)
+	}
+
+	return boards[moveN], nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// playFirstLegalMoves plays the first legal move of each turn until the game finishes
+func playFirstLegalMoves(n int) Record {
+	b := NewBoard(n)
+	r := Record{N: n, Moves: make([]string, 0)}
+
+	for b.HasLegalMove(Black) || b.HasLegalMove(White) {
+		if !b.HasLegalMove(b.Turn) {
+			b = b.CopyBoard()
+			b.SwitchTurn()
+			r.Moves = append(r.Moves, PassString)
+			continue
+		}
+
+		for cell := 0; cell < b.CellN; cell++ {
+			if b.IsLegal(cell, b.Turn) {
+				p := cellToPosition(n, cell)
+				b, _ = b.Place(p)
+				r.Moves = append(r.Moves, p.String())
+				break
+			}
+		}
+	}
+
+	return r
+}
+
+func TestRecordSaveAndLoad(t *testing.T) {
+	r := playFirstLegalMoves(6)
+	r.Black, r.White = "Alice", "Bob"
+
+	path := filepath.Join(t.TempDir(), "game.json")
+	assert.Nil(t, r.Save(path))
+
+	loaded, err := LoadRecord(path)
+	assert.Nil(t, err)
+	assert.Equal(t, r, loaded)
+}
+
+func TestRecordParsePlain(t *testing.T) {
+	r, err := ParseRecord([]byte("# a game\n4x4\nb1 A1\n-- c4\n"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, Record{N: 4, Moves: []string{"b1", "a1", "--", "c4"}}, r)
+
+	r, err = ParseRecord([]byte("e3 f3"))
+	assert.Nil(t, err)
+	assert.Equal(t, DEFAULT_N, r.N)
+
+	_, err = ParseRecord([]byte("e3 f33x"))
+	assert.NotNil(t, err)
+}
+
+func TestRecordPositions(t *testing.T) {
+	r := playFirstLegalMoves(6)
+	assert.Contains(t, r.Moves, PassString)
+
+	boards, moves, err := r.Positions()
+	assert.Nil(t, err)
+	assert.Equal(t, len(r.Moves)+1, len(boards))
+	assert.Equal(t, len(r.Moves), len(moves))
+
+	last := boards[len(boards)-1]
+	assert.False(t, last.HasLegalMove(Black) || last.HasLegalMove(White))
+
+	totalB, totalW := last.Count()
+	assert.Equal(t, totalB, moves[len(moves)-1].Black)
+	assert.Equal(t, totalW, moves[len(moves)-1].White)
+
+	// passes are added if the record doesn't have them
+	withoutPass := Record{N: r.N, Moves: slices.DeleteFunc(slices.Clone(r.Moves), func(m string) bool { return m == PassString })}
+
+	boards2, moves2, err := withoutPass.Positions()
+	assert.Nil(t, err)
+	assert.Equal(t, moves, moves2)
+	assert.Equal(t, boardRows(last), boardRows(boards2[len(boards2)-1]))
+}
+
+func TestRecordIllegalMove(t *testing.T) {
+	cases := []struct {
+		Moves   []string
+		Boards  int
+		Message string
+	}{
+		{[]string{"e3", "a1"}, 2, "Move 2 (a1) is illegal: You can't place there."},
+		{[]string{"e3", "--"}, 2, "Move 2 (--) is illegal: White has a legal move"},
+		{[]string{"e3", "i9"}, 2, "Move 2 (i9) is illegal: out of the board"},
+	}
+
+	for _, c := range cases {
+		boards, _, err := Record{N: 8, Moves: c.Moves}.Positions()
+
+		assert.EqualError(t, err, c.Message)
+		assert.Equal(t, c.Boards, len(boards))
+	}
+}
+
+func TestGameRecord(t *testing.T) {
+	g := NewGame(NewBoard(3), Human, AI)
+	g.Moves = []Move{
+		{Colour: Black, Position: Position{0, 0}},
+		{Colour: White, Pass: true},
+	}
+
+	assert.Equal(t, Record{N: 3, Black: "Player 1", White: "Player 2 (AI)", Moves: []string{"a1", "--"}}, g.Record())
+}
+
+func TestParseBoardRows(t *testing.T) {
+	b, err := parseBoardRows("---/-XO/-ox", White)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"---", "-XO", "-OX"}, boardRows(b))
+	assert.Equal(t, White, b.Turn)
+	assert.True(t, b.HasLegalMove(White))
+
+	rect, err := parseBoardRows("-----/-XO--/-OX--", Black)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, rect.W)
+	assert.Equal(t, 3, rect.H)
+
+	for _, s := range []string{"--/-X", "---/-XO/-O", "---/-XO/-OZ"} {
+		_, err := parseBoardRows(s, Black)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestRecordRectangle(t *testing.T) {
+	g := NewGame(NewRectBoard(10, 6, Variant{}), Human, Human)
+	g.place(Position{5, 1})
+
+	r := g.Record()
+	assert.Equal(t, Record{N: 10, Width: 10, Height: 6, Black: r.Black, White: r.White, Moves: []string{"f2"}}, r)
+
+	boards, _, err := r.Positions()
+	assert.Nil(t, err)
+	assert.Equal(t, boardRows(g.Board), boardRows(boards[1]))
+
+	// the move out of the height
+	r.Moves = []string{"f7"}
+	_, _, err = r.Positions()
+	assert.EqualError(t, err, "Move 1 (f7) is illegal: out of the board")
+
+	plain, err := ParseRecord([]byte("6x10\nd3"))
+	assert.Nil(t, err)
+	assert.Equal(t, Record{N: 10, Width: 6, Height: 10, Moves: []string{"d3"}}, plain)
+
+	_, _, err = Record{N: 12, Width: 12, Height: 6}.Positions()
+	assert.EqualError(t, err, "Board sides must be between 3 and 10: 12x6")
+}
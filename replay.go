@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	messageReplayStart = "Start of the game (%d moves)"
+	messageReplayMove  = "Move %d/%d: %s  %s"
+)
+
+// ReplayViewer steps through the positions of a record
+type ReplayViewer struct {
+	Eval bool // analyze the moves to flag inaccuracies, mistakes and blunders
+
+	record  Record
+	boards  []*Board
+	moves   []Move
+	err     error // the first illegal move in the record
+	i       int   // the board shown, after moves[:i]
+	inputCh <-chan string
+	d       Renderer
+	mu      *sync.Mutex
+	evals   map[int]*moveEval // evaluation of moves[i-1], nil while analyzing
+}
+
+// moveEval is the evaluation of a played move
+type moveEval struct {
+	best   MoveAnalysis
+	played MoveAnalysis
+}
+
+// loss is how much worse the played move is than the best move
+func (e *moveEval) loss() int {
+	return e.best.Score - e.played.Score
+}
+
+// NewReplayViewer reconstructs the positions of the record.
+// If the record has an illegal move, the positions before it are shown with the error
+func NewReplayViewer(r Record, inputCh <-chan string, d Renderer) (*ReplayViewer, error) {
+	boards, moves, err := r.Positions()
+	if boards == nil {
+		return nil, err
+	}
+
+	v := &ReplayViewer{
+		record:  r,
+		boards:  boards,
+		moves:   moves,
+		err:     err,
+		inputCh: inputCh,
+		d:       d,
+		mu:      &sync.Mutex{},
+		evals:   make(map[int]*moveEval),
+	}
+
+	return v, nil
+}
+
+// Run shows the positions until the user quits
+func (v *ReplayViewer) Run() {
+	v.render()
+
+	for char := range v.inputCh {
+		action := keyAction(char)
+		if action == ActionQuit {
+			return
+		}
+
+		v.handle(action)
+	}
+}
+
+func (v *ReplayViewer) handle(action KeyAction) {
+	v.mu.Lock()
+
+	switch action {
+	case ActionLeft:
+		v.i = max(v.i-1, 0)
+	case ActionRight:
+		v.i = min(v.i+1, len(v.boards)-1)
+	case ActionUp:
+		v.i = 0
+	case ActionDown:
+		v.i = len(v.boards) - 1
+	}
+
+	v.mu.Unlock()
+
+	v.render()
+}
+
+func (v *ReplayViewer) render() {
+	if v.Eval {
+		v.evaluate(v.current())
+	}
+
+	g := v.game()
+
+	// mark the last move
+	p := Position{-1, -1}
+	if len(g.Moves) > 0 && !g.Moves[len(g.Moves)-1].Pass {
+		p = g.Moves[len(g.Moves)-1].Position
+	}
+
+	v.d.Render(g, p)
+}
+
+func (v *ReplayViewer) current() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.i
+}
+
+// game returns the game to render for the current position
+func (v *ReplayViewer) game() *Game {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	g := NewGame(v.boards[v.i], Human, Human)
+	if v.record.Black != "" {
+		g.Player1.Name = v.record.Black
+	}
+	if v.record.White != "" {
+		g.Player2.Name = v.record.White
+	}
+
+	g.State = Replaying
+	g.Moves = v.moves[:v.i]
+	g.Message = v.message()
+
+	return &g
+}
+
+func (v *ReplayViewer) message() string {
+	parts := make([]string, 0)
+
+	if v.i == 0 {
+		parts = append(parts, fmt.Sprintf(messageReplayStart, len(v.moves)))
+	} else {
+		m := v.moves[v.i-1]
+		parts = append(parts, fmt.Sprintf(messageReplayMove, v.i, len(v.moves), m.Colour, formatMove(m)))
+	}
+
+	if v.Eval && v.i > 0 && !v.moves[v.i-1].Pass {
+		e, ok := v.evals[v.i]
+		switch {
+		case !ok || e == nil:
+			parts = append(parts, messageAnalyzing)
+		default:
+			parts = append(parts, formatMoveEval(e))
+		}
+	}
+
+	if v.i == len(v.boards)-1 && v.err != nil {
+		parts = append(parts, "🚨  "+v.err.Error())
+	}
+
+	return strings.Join(parts, " | ")
+}
+
+// evaluate analyzes the move played to reach boards[i] in the background, and renders again when it's done
+func (v *ReplayViewer) evaluate(i int) {
+	if i == 0 || v.moves[i-1].Pass {
+		return
+	}
+
+	v.mu.Lock()
+	if _, ok := v.evals[i]; ok {
+		v.mu.Unlock()
+		return
+	}
+	v.evals[i] = nil
+	v.mu.Unlock()
+
+	go func() {
+		e := evaluateMove(v.boards[i-1], v.moves[i-1].Position)
+
+		v.mu.Lock()
+		v.evals[i] = e
+		shown := v.i == i
+		v.mu.Unlock()
+
+		if shown {
+			v.render()
+		}
+	}()
+}
+
+// evaluateMove compares the move played on the board with the best move
+func evaluateMove(b *Board, played Position) *moveEval {
+	results := newAiPlayerFor(b).Analyze(b.CopyBoard(), uiAnalysisDepth, uiAnalysisTime)
+
+	e := &moveEval{best: results[0]}
+	for _, r := range results {
+		if r.Position == played {
+			e.played = r
+		}
+	}
+
+	return e
+}
+
+func formatMoveEval(e *moveEval) string {
+	s := fmt.Sprintf("Eval %+d, best %s %+d", e.played.Score, e.best.Position, e.best.Score)
+
+	if label := classifyMove(e.loss()).label(); label != "" {
+		s += " " + label
+	}
+
+	return s
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayViewerSteps(t *testing.T) {
+	r := Record{N: 8, Black: "Alice", Moves: []string{"e3", "f3", "c5"}}
+
+	d := &MockDisplay{}
+	v, err := NewReplayViewer(r, nil, d)
+	assert.Nil(t, err)
+
+	g := v.game()
+	assert.Equal(t, Replaying, g.State)
+	assert.Equal(t, "Alice", g.Player1.Name)
+	assert.Equal(t, "Player 2", g.Player2.Name)
+	assert.Equal(t, "Start of the game (3 moves)", g.Message)
+
+	v.handle(ActionRight)
+	v.handle(ActionRight)
+	assert.Equal(t, "Move 2/3: ●  f3", d.g.Message)
+	assert.Equal(t, Position{5, 2}, d.p)
+	assert.Equal(t, 2, len(d.g.Moves))
+
+	v.handle(ActionLeft)
+	assert.Equal(t, Position{4, 2}, d.p)
+
+	v.handle(ActionDown)
+	assert.Equal(t, "Move 3/3: ○  c5", d.g.Message)
+
+	// stays at the end
+	v.handle(ActionRight)
+	assert.Equal(t, 3, len(d.g.Moves))
+
+	v.handle(ActionUp)
+	assert.Equal(t, 0, len(d.g.Moves))
+	assert.Equal(t, Position{-1, -1}, d.p)
+
+	// stays at the start
+	v.handle(ActionLeft)
+	assert.Equal(t, 0, len(d.g.Moves))
+}
+
+func TestReplayViewerIllegalMove(t *testing.T) {
+	r := Record{N: 8, Moves: []string{"e3", "f3", "a1", "c5"}}
+
+	d := &MockDisplay{}
+	v, err := NewReplayViewer(r, nil, d)
+	assert.Nil(t, err)
+
+	v.handle(ActionDown)
+	assert.Equal(t, 2, len(d.g.Moves))
+	assert.Contains(t, d.g.Message, "Move 3 (a1) is illegal")
+
+	_, err = NewReplayViewer(Record{N: 2}, nil, d)
+	assert.NotNil(t, err)
+}
+
+func TestReplayViewerEval(t *testing.T) {
+	r := Record{N: 4, Moves: []string{"c1"}}
+
+	d := &MockDisplay{}
+	v, err := NewReplayViewer(r, nil, d)
+	assert.Nil(t, err)
+	v.Eval = true
+
+	v.handle(ActionRight)
+
+	assert.Eventually(t, func() bool {
+		return v.game().Message != "Move 1/1: ○  c1 | "+messageAnalyzing
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Regexp(t, `^Move 1/1: ○  c1 \| Eval [+-]\d+, best [a-d][1-4] [+-]\d+`, v.game().Message)
+}
+
+func TestFormatMoveEval(t *testing.T) {
+	e := &moveEval{
+		best:   MoveAnalysis{Position: Position{0, 0}, Score: 12},
+		played: MoveAnalysis{Position: Position{1, 1}, Score: 2},
+	}
+
+	assert.Equal(t, 10, e.loss())
+	assert.Equal(t, "Eval +2, best a1 +12 ⚠️  Blunder", formatMoveEval(e))
+
+	e.played.Score = 8
+	assert.Equal(t, "Eval +8, best a1 +12 ?! Inaccuracy", formatMoveEval(e))
+
+	e.played.Score = 11
+	assert.Equal(t, "Eval +11, best a1 +12", formatMoveEval(e))
+}
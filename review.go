@@ -0,0 +1,328 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// score loss of a move for each class. Smaller losses are counted as the best move
+	inaccuracyLoss = 3
+	mistakeLoss    = 6
+	blunderLoss    = 10
+
+	// the number of worst moves in the review
+	reviewWorstMoves = 3
+
+	// the review on the finished screen, for each move
+	uiReviewDepth = 5
+	uiReviewTime  = 300 * time.Millisecond
+
+	messageReviewing = "📋  Reviewing the game... %d/%d"
+	messageNoReview  = "There is no move to review."
+)
+
+type MoveClass int
+
+const (
+	ClassBest MoveClass = iota
+	ClassInaccuracy
+	ClassMistake
+	ClassBlunder
+)
+
+func (c MoveClass) String() string {
+	switch c {
+	case ClassBest:
+		return "best"
+	case ClassInaccuracy:
+		return "inaccuracy"
+	case ClassMistake:
+		return "mistake"
+	case ClassBlunder:
+		return "blunder"
+	default:
+		return "unknown"
+	}
+}
+
+func (c MoveClass) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// label is shown next to the move
+func (c MoveClass) label() string {
+	switch c {
+	case ClassInaccuracy:
+		return "?! Inaccuracy"
+	case ClassMistake:
+		return "? Mistake"
+	case ClassBlunder:
+		return "⚠️  Blunder"
+	default:
+		return ""
+	}
+}
+
+func classifyMove(loss int) MoveClass {
+	switch {
+	case loss >= blunderLoss:
+		return ClassBlunder
+	case loss >= mistakeLoss:
+		return ClassMistake
+	case loss >= inaccuracyLoss:
+		return ClassInaccuracy
+	default:
+		return ClassBest
+	}
+}
+
+// MoveReview is the evaluation of a played move
+type MoveReview struct {
+	Number    int       `json:"number"` // 1 for the first move, passes are counted
+	Colour    string    `json:"colour"`
+	Move      string    `json:"move"`
+	Score     int       `json:"score"`
+	Best      string    `json:"best"`
+	BestScore int       `json:"best_score"`
+	Loss      int       `json:"loss"`
+	Class     MoveClass `json:"class"`
+	Depth     int       `json:"depth"` // the depth searched in the time limit
+}
+
+// PlayerSummary counts the classes of the moves of a player
+type PlayerSummary struct {
+	Name         string  `json:"name"`
+	Moves        int     `json:"moves"`
+	Best         int     `json:"best"`
+	Inaccuracies int     `json:"inaccuracies"`
+	Mistakes     int     `json:"mistakes"`
+	Blunders     int     `json:"blunders"`
+	AverageLoss  float64 `json:"average_loss"`
+}
+
+// Review is the result of re-analyzing every move of a game
+type Review struct {
+	Depth int           `json:"depth"` // the maximum depth
+	Black PlayerSummary `json:"black"`
+	White PlayerSummary `json:"white"`
+	Worst []MoveReview  `json:"worst"`
+	Moves []MoveReview  `json:"moves"`
+}
+
+// ReviewRecord analyzes every move of the record in parallel.
+// progress is called with the number of moves analyzed, if not nil
+func ReviewRecord(r Record, depth int, timeLimit time.Duration, progress func(done, total int)) (Review, error) {
+	boards, moves, err := r.Positions()
+	if err != nil {
+		return Review{}, err
+	}
+
+	// passes are not reviewed
+	indexes := make([]int, 0, len(moves))
+	for i, m := range moves {
+		if !m.Pass {
+			indexes = append(indexes, i)
+		}
+	}
+
+	reviews := make([]MoveReview, len(indexes))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := range jobs {
+				i := indexes[j]
+				reviews[j] = reviewMove(boards[i], moves[i], i+1, depth, timeLimit)
+
+				if progress != nil {
+					mu.Lock()
+					done++
+					progress(done, len(indexes))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for j := range indexes {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+
+	review := Review{
+		Depth: depth,
+		Black: summarize(r.Black, "Black", reviews),
+		White: summarize(r.White, "White", reviews),
+		Moves: reviews,
+		Worst: worstMoves(reviews, reviewWorstMoves),
+	}
+
+	return review, nil
+}
+
+func reviewMove(b *Board, m Move, number int, depth int, timeLimit time.Duration) MoveReview {
+	results := newAiPlayerFor(b).Analyze(b.CopyBoard(), depth, timeLimit)
+
+	review := MoveReview{
+		Number:    number,
+		Colour:    colourName(m.Colour),
+		Move:      formatMove(m),
+		Best:      results[0].Position.String(),
+		BestScore: results[0].Score,
+		Depth:     results[0].Depth,
+	}
+
+	for _, r := range results {
+		if r.Position == m.Position {
+			review.Score = r.Score
+		}
+	}
+
+	review.Loss = review.BestScore - review.Score
+	review.Class = classifyMove(review.Loss)
+
+	return review
+}
+
+func summarize(name string, colour string, reviews []MoveReview) PlayerSummary {
+	if name == "" {
+		name = colour
+	}
+
+	s := PlayerSummary{Name: name}
+	totalLoss := 0
+
+	for _, r := range reviews {
+		if r.Colour != colour {
+			continue
+		}
+
+		s.Moves++
+		totalLoss += r.Loss
+
+		switch r.Class {
+		case ClassBest:
+			s.Best++
+		case ClassInaccuracy:
+			s.Inaccuracies++
+		case ClassMistake:
+			s.Mistakes++
+		case ClassBlunder:
+			s.Blunders++
+		}
+	}
+
+	if s.Moves > 0 {
+		s.AverageLoss = float64(totalLoss) / float64(s.Moves)
+	}
+
+	return s
+}
+
+// worstMoves returns the moves with the biggest losses, excluding the best moves
+func worstMoves(reviews []MoveReview, n int) []MoveReview {
+	worst := make([]MoveReview, 0, n)
+	for _, r := range reviews {
+		if r.Class != ClassBest {
+			worst = append(worst, r)
+		}
+	}
+
+	sort.SliceStable(worst, func(i, j int) bool { return worst[i].Loss > worst[j].Loss })
+
+	return worst[:min(n, len(worst))]
+}
+
+func (s PlayerSummary) String() string {
+	return fmt.Sprintf(
+		"%s: %d best, %d inaccuracies, %d mistakes, %d blunders",
+		s.Name, s.Best, s.Inaccuracies, s.Mistakes, s.Blunders,
+	)
+}
+
+func (r MoveReview) String() string {
+	return fmt.Sprintf("%d.%s %+d (best %s)", r.Number, r.Move, -r.Loss, r.Best)
+}
+
+// Summary returns the review in a few lines for the finished screen
+func (r Review) Summary() string {
+	if len(r.Moves) == 0 {
+		return messageNoReview
+	}
+
+	lines := []string{
+		fmt.Sprintf("📋  Review (max depth %d)", r.Depth),
+		fmt.Sprintf("%s %s", BlackString, r.Black),
+		fmt.Sprintf("%s %s", WhiteString, r.White),
+	}
+
+	if len(r.Worst) > 0 {
+		worst := make([]string, 0, len(r.Worst))
+		for _, m := range r.Worst {
+			worst = append(worst, m.String())
+		}
+		lines = append(lines, "Worst: "+strings.Join(worst, "  "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// WriteText writes the summary and every move
+func (r Review) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Review (max depth %d)\n\n", r.Depth)
+
+	for _, s := range []PlayerSummary{r.Black, r.White} {
+		fmt.Fprintf(w, "%s, average loss %.1f\n", s, s.AverageLoss)
+	}
+
+	if len(r.Worst) > 0 {
+		fmt.Fprintln(w, "\nWorst moves:")
+		for _, m := range r.Worst {
+			fmt.Fprintf(w, "  %s  %s\n", m, m.Class)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%4s %-6s %-4s %6s %-4s %6s %5s %5s  %s\n", "No.", "Colour", "Move", "Score", "Best", "Score", "Loss", "Depth", "Class")
+	for _, m := range r.Moves {
+		fmt.Fprintf(w, "%4d %-6s %-4s %+6d %-4s %+6d %5d %5d  %s\n", m.Number, m.Colour, m.Move, m.Score, m.Best, m.BestScore, m.Loss, m.Depth, m.Class)
+	}
+}
+
+func (r Review) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// showReview reviews the game in the background and shows the summary
+func showReview(g *Game, d Renderer) {
+	r := g.Record()
+
+	go func() {
+		review, err := ReviewRecord(r, uiReviewDepth, uiReviewTime, func(done, total int) {
+			d.Notify(fmt.Sprintf(messageReviewing, done, total))
+		})
+
+		if err != nil {
+			d.Notify(err.Error())
+			return
+		}
+
+		d.Notify(review.Summary())
+	}()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyMove(t *testing.T) {
+	assert.Equal(t, ClassBest, classifyMove(0))
+	assert.Equal(t, ClassBest, classifyMove(inaccuracyLoss-1))
+	assert.Equal(t, ClassInaccuracy, classifyMove(inaccuracyLoss))
+	assert.Equal(t, ClassMistake, classifyMove(mistakeLoss))
+	assert.Equal(t, ClassBlunder, classifyMove(blunderLoss+5))
+}
+
+func TestReviewRecord(t *testing.T) {
+	r := playFirstLegalMoves(4)
+	r.Black = "Alice"
+
+	progress := 0
+	review, err := ReviewRecord(r, 3, time.Second, func(done, total int) { progress = done })
+	assert.Nil(t, err)
+
+	reviewed := 0
+	for _, m := range r.Moves {
+		if m != PassString {
+			reviewed++
+		}
+	}
+	assert.Equal(t, reviewed, len(review.Moves))
+	assert.Equal(t, reviewed, progress)
+
+	assert.Equal(t, "Alice", review.Black.Name)
+	assert.Equal(t, "White", review.White.Name)
+	assert.Equal(t, reviewed, review.Black.Moves+review.White.Moves)
+
+	for _, m := range review.Moves {
+		assert.GreaterOrEqual(t, m.Loss, 0)
+		assert.Equal(t, classifyMove(m.Loss), m.Class)
+		assert.Equal(t, r.Moves[m.Number-1], m.Move)
+	}
+
+	for i, m := range review.Worst {
+		assert.NotEqual(t, ClassBest, m.Class)
+		if i > 0 {
+			assert.LessOrEqual(t, m.Loss, review.Worst[i-1].Loss)
+		}
+	}
+
+	_, err = ReviewRecord(Record{N: 8, Moves: []string{"a1"}}, 3, time.Second, nil)
+	assert.NotNil(t, err)
+}
+
+func TestReviewReports(t *testing.T) {
+	review := Review{
+		Depth: 5,
+		Black: PlayerSummary{Name: "Alice", Moves: 2, Best: 1, Blunders: 1, AverageLoss: 6},
+		White: PlayerSummary{Name: "Bob", Moves: 1, Best: 1},
+		Moves: []MoveReview{
+			{1, "Black", "e3", 2, "e3", 2, 0, ClassBest, 5},
+			{2, "White", "f3", 0, "f3", 0, 0, ClassBest, 5},
+			{3, "Black", "c5", -8, "f4", 4, 12, ClassBlunder, 5},
+		},
+	}
+	review.Worst = worstMoves(review.Moves, reviewWorstMoves)
+
+	assert.Equal(t, strings.Join([]string{
+		"📋  Review (max depth 5)",
+		BlackString + " Alice: 1 best, 0 inaccuracies, 0 mistakes, 1 blunders",
+		WhiteString + " Bob: 1 best, 0 inaccuracies, 0 mistakes, 0 blunders",
+		"Worst: 3.c5 -12 (best f4)",
+	}, "\n"), review.Summary())
+
+	out := &bytes.Buffer{}
+	review.WriteText(out)
+	assert.Contains(t, out.String(), "Alice: 1 best, 0 inaccuracies, 0 mistakes, 1 blunders, average loss 6.0")
+	assert.Contains(t, out.String(), "   3 Black  c5       -8 f4       +4    12     5  blunder\n")
+
+	out = &bytes.Buffer{}
+	assert.Nil(t, review.WriteJSON(out))
+
+	var decoded map[string]any
+	assert.Nil(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, "blunder", decoded["worst"].([]any)[0].(map[string]any)["class"])
+
+	assert.Equal(t, messageNoReview, Review{}.Summary())
+}
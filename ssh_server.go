@@ -0,0 +1,357 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	messageSshWaiting = "⏳  Waiting for another player... Press a to play against the AI, %s to quit."
+	defaultPtyWidth   = 80
+	defaultPtyHeight  = 24
+)
+
+// SshServer lets people play over SSH. Each session gets its own display,
+// and players are paired with the next one to connect, or play against the AI
+type SshServer struct {
+	W           int
+	H           int
+	Variant     Variant // every game starts with the variant, e.g., from a position
+	Port        int
+	HostKeyPath string
+	AiLevel     int
+
+	config   *ssh.ServerConfig
+	listener net.Listener
+	mu       sync.Mutex
+	waiting  *sshPlayer // the player waiting for an opponent
+}
+
+// sshPlayer is one SSH session
+type sshPlayer struct {
+	name    string
+	d       *Display
+	inputCh chan string
+	startCh chan sshSeat  // receives a seat when another player joins
+	done    chan struct{} // closed when the player leaves
+}
+
+// sshSeat is the channels for a player in a game
+type sshSeat struct {
+	gameCh chan Game
+	cmdCh  chan GameCommand
+	quitCh chan bool
+	id     PlayerId
+}
+
+func NewSshServer(w, h int, v Variant, port int, hostKeyPath string) (*SshServer, error) {
+	signer, err := loadOrCreateHostKey(hostKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &ssh.ServerConfig{
+		// anyone can play
+		NoClientAuth: true,
+	}
+	config.AddHostKey(signer)
+
+	s := &SshServer{
+		W:           w,
+		H:           h,
+		Variant:     v,
+		Port:        port,
+		HostKeyPath: hostKeyPath,
+		AiLevel:     DEFAULT_AI_LEVEL,
+		config:      config,
+	}
+
+	return s, nil
+}
+
+// loadOrCreateHostKey reads the host key, or generates one on first start
+func loadOrCreateHostKey(path string) (ssh.Signer, error) {
+	bytes, err := os.ReadFile(path)
+	if err == nil {
+		return ssh.ParsePrivateKey(bytes)
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("Failed to read host key: %w", err)
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to generate host key: %w", err)
+	}
+
+	block, err := ssh.MarshalPrivateKey(key, "go-reversi")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to encode host key: %w", err)
+	}
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		return nil, fmt.Errorf("Failed to save host key: %w", err)
+	}
+
+	logger.Info("Generated SSH host key", slog.String("path", path))
+
+	return ssh.NewSignerFromKey(key)
+}
+
+func (s *SshServer) ListenAndServe() error {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", s.Port))
+	if err != nil {
+		return fmt.Errorf("Error starting server: %w", err)
+	}
+
+	return s.Serve(l)
+}
+
+func (s *SshServer) Serve(l net.Listener) error {
+	s.listener = l
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("Error accepting connection: %w", err)
+		}
+
+		go s.handleConn(conn)
+	}
+}
+
+func (s *SshServer) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+func (s *SshServer) handleConn(conn net.Conn) {
+	sshConn, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		logger.Debug("SSH handshake failed", slog.Any("err", err))
+		return
+	}
+	defer sshConn.Close()
+
+	logger.Debug("SSH connected", slog.String("user", sshConn.User()))
+
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only session is supported")
+			continue
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			logger.Debug("SSH channel error", slog.Any("err", err))
+			continue
+		}
+
+		go s.handleSession(sshConn.User(), channel, requests)
+	}
+}
+
+func (s *SshServer) handleSession(user string, channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+
+	width, height := defaultPtyWidth, defaultPtyHeight
+	var d *Display
+
+	// wait for the shell, then keep handling resizes
+	for req := range requests {
+		switch req.Type {
+		case "pty-req":
+			width, height = parsePtyRequest(req.Payload)
+			req.Reply(true, nil)
+		case "window-change":
+			w, h := parseWindowChange(req.Payload)
+			if d != nil {
+				d.Resize(w, h)
+			}
+			width, height = w, h
+		case "shell":
+			req.Reply(true, nil)
+
+			d = NewDisplayFrom(channel, width, height)
+
+			go func() {
+				s.play(user, d)
+				d.Close()
+				channel.Close()
+			}()
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// play pairs the player with the waiting one, or waits for an opponent
+func (s *SshServer) play(user string, d *Display) {
+	p := &sshPlayer{
+		name:    user,
+		d:       d,
+		inputCh: make(chan string),
+		startCh: make(chan sshSeat),
+		done:    make(chan struct{}),
+	}
+	defer close(p.done)
+
+	go func() {
+		for {
+			if err := d.Read(p.inputCh); err != nil {
+				// the session is closed, leave the game
+				select {
+				case p.inputCh <- "\x03":
+				case <-p.done:
+				}
+				return
+			}
+		}
+	}()
+
+	s.mu.Lock()
+	opponent := s.waiting
+	if opponent == nil {
+		s.waiting = p
+	} else {
+		s.waiting = nil
+	}
+	s.mu.Unlock()
+
+	if opponent != nil {
+		s.startPairGame(opponent, p)
+		return
+	}
+
+	d.Notify(fmt.Sprintf(messageSshWaiting, keyLabel(ActionQuit)))
+
+	quitting := false
+
+	for {
+		select {
+		case seat := <-p.startCh:
+			if quitting {
+				// the opponent joined while quitting
+				seat.quitCh <- true
+				return
+			}
+			p.run(seat)
+			return
+
+		case char := <-p.inputCh:
+			switch {
+			case char == "a":
+				if s.leaveWaiting(p) {
+					s.startAiGame(p)
+					return
+				}
+			case keyAction(char) == ActionQuit:
+				if s.leaveWaiting(p) {
+					return
+				}
+				quitting = true
+			}
+		}
+	}
+}
+
+// leaveWaiting returns false if another player has already joined
+func (s *SshServer) leaveWaiting(p *sshPlayer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.waiting != p {
+		return false
+	}
+
+	s.waiting = nil
+	return true
+}
+
+func (s *SshServer) startPairGame(p1, p2 *sshPlayer) {
+	g := NewGame(s.newBoard(), Human, Human)
+	if p1.name != "" && p2.name != "" {
+		g.Player1.Name, g.Player2.Name = p1.name, p2.name
+	}
+
+	player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, player1QuitCh, player2QuitCh := g.Start()
+
+	p1.startCh <- sshSeat{player1GameCh, player1CmdCh, player1QuitCh, Player1Id}
+
+	p2.run(sshSeat{player2GameCh, player2CmdCh, player2QuitCh, Player2Id})
+}
+
+func (s *SshServer) startAiGame(p *sshPlayer) {
+	b := s.newBoard()
+	g := NewGame(b, Human, AI)
+	if p.name != "" {
+		g.Player1.Name = p.name
+	}
+
+	player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, player1QuitCh, player2QuitCh := g.Start()
+
+	cli := NewAiClient(b.N, s.AiLevel, player2GameCh, player2CmdCh, player2QuitCh, Player2Id)
+	cli.p = NewRectAiPlayer(b.W, b.H, s.AiLevel)
+	go cli.Run()
+
+	p.run(sshSeat{player1GameCh, player1CmdCh, player1QuitCh, Player1Id})
+}
+
+// run plays until the player quits
+func (p *sshPlayer) run(seat sshSeat) {
+	closeCh := make(chan bool)
+
+	cli := NewLocalClient(seat.gameCh, seat.cmdCh, seat.quitCh, p.inputCh, closeCh, seat.id, p.d)
+	go cli.Run()
+
+	<-closeCh
+}
+
+// parsePtyRequest returns the width and height of "pty-req" (RFC 4254 6.2)
+func parsePtyRequest(payload []byte) (int, int) {
+	// skip the TERM environment variable
+	if len(payload) < 4 {
+		return defaultPtyWidth, defaultPtyHeight
+	}
+	termLen := int(binary.BigEndian.Uint32(payload))
+	if len(payload) < 4+termLen {
+		return defaultPtyWidth, defaultPtyHeight
+	}
+
+	return parseWindowChange(payload[4+termLen:])
+}
+
+// parseWindowChange returns the width and height of "window-change" (RFC 4254 6.7)
+func parseWindowChange(payload []byte) (int, int) {
+	if len(payload) < 8 {
+		return defaultPtyWidth, defaultPtyHeight
+	}
+
+	width := int(binary.BigEndian.Uint32(payload))
+	height := int(binary.BigEndian.Uint32(payload[4:]))
+
+	return width, height
+}
+
+// newBoard returns the board a game starts with
+func (s *SshServer) newBoard() *Board {
+	return NewRectBoard(s.W, s.H, s.Variant)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSshServerHostKeyIsReused(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	path := filepath.Join(t.TempDir(), "host_key")
+
+	first, err := loadOrCreateHostKey(path)
+	assert.Nil(t, err)
+
+	second, err := loadOrCreateHostKey(path)
+	assert.Nil(t, err)
+
+	assert.Equal(t, first.PublicKey().Marshal(), second.PublicKey().Marshal())
+}
+
+func TestSshServerBoardVariant(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	v := Variant{Rule: RuleAnti, Holes: []string{"a1"}}
+	s, err := NewSshServer(6, 4, v, 0, filepath.Join(t.TempDir(), "host_key"))
+	assert.Nil(t, err)
+
+	b := s.newBoard()
+	assert.Equal(t, 6, b.W)
+	assert.Equal(t, 4, b.H)
+	assert.Equal(t, RuleAnti, b.Variant.Rule)
+	assert.True(t, b.IsHole(Position{0, 0}))
+}
+
+func TestSshServerPlayAgainstAi(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	s, err := NewSshServer(4, 4, Variant{}, 0, filepath.Join(t.TempDir(), "host_key"))
+	assert.Nil(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	go s.Serve(l)
+	defer s.Close()
+
+	client, err := ssh.Dial("tcp", l.Addr().String(), &ssh.ClientConfig{
+		User:            "alice",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatalf("Dial error: %v", err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	assert.Nil(t, err)
+
+	out := &sshTestOutput{}
+	session.Stdout = out
+	stdin, _ := session.StdinPipe()
+
+	assert.Nil(t, session.RequestPty("xterm", 40, 100, ssh.TerminalModes{}))
+	assert.Nil(t, session.Shell())
+
+	waitForOutput(t, out, "Waiting for another player")
+
+	// play against the AI
+	stdin.Write([]byte("a"))
+	waitForOutput(t, out, "alice")
+	waitForOutput(t, out, "Player 2 (AI)")
+
+	// quit with Ctrl + C
+	stdin.Write([]byte{3})
+
+	done := make(chan error)
+	go func() { done <- session.Wait() }()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Session is not closed")
+	}
+}
+
+type sshTestOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *sshTestOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.Write(p)
+}
+
+func (o *sshTestOutput) Contains(s string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return strings.Contains(o.buf.String(), s)
+}
+
+func waitForOutput(t *testing.T, out *sshTestOutput, s string) {
+	t.Helper()
+
+	for i := 0; i < 100; i++ {
+		if out.Contains(s) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Errorf("Output doesn't contain %q", s)
+}
@@ -10,11 +10,10 @@ type HostStarter struct {
 	d       Renderer
 	g       Game
 	inputCh chan string
+	name    string // name of the host player, default if empty
 }
 
-func (hs *HostStarter) Start(n int, port int) {
-	b := NewBoard(n)
-
+func (hs *HostStarter) Start(b *Board, port int) {
 	hs.g = NewGame(b, Human, Human)
 
 	player1CmdCh, hostCmdCh, player1GameCh, hostGameCh, player1QuitCh, hostQuitCh := hs.g.Start()
@@ -30,6 +29,7 @@ func (hs *HostStarter) Start(n int, port int) {
 		Player1Id,
 		hs.d,
 	)
+	cli1.Name = hs.name
 
 	hostConn := NewOnlineHostConnection(
 		hostGameCh,
@@ -58,6 +58,7 @@ func (hs *HostStarter) Start(n int, port int) {
 type GuestStarter struct {
 	d       Renderer
 	inputCh chan string
+	name    string // name of the guest player, default if empty
 }
 
 func (gs *GuestStarter) Start(url string, port int) {
@@ -69,9 +70,10 @@ func (gs *GuestStarter) Start(url string, port int) {
 	closeCh := make(chan bool)
 
 	cli := NewLocalClient(gameCh, cmdCh, quitCh, gs.inputCh, closeCh, id, gs.d)
+	cli.Name = gs.name
 
 	go func() {
-		fmt.Print("Trying to connect to the server. Press Ctrl + C to quit...")
+		gs.d.Notify("Trying to connect to the server. Press Ctrl + C to quit...")
 
 		cli.Run()
 		logger.Debug("Client closed")
@@ -82,7 +84,7 @@ func (gs *GuestStarter) Start(url string, port int) {
 
 		err := conn.Run()
 		if err != nil {
-			fmt.Printf("\r\033[KCan't connect %s. Press Ctrl + C to quit.\n", conn.Url)
+			gs.d.Notify(fmt.Sprintf("Can't connect %s. Press Ctrl + C to quit.", conn.Url))
 			logger.Debug("Error on guest conn", slog.Any("err", err))
 		}
 		logger.Debug("Guest conn closed")
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Symmetry is one of the 8 rotations and reflections of the board
+type Symmetry int
+
+const (
+	Identity         Symmetry = iota
+	Rotate90                  // clockwise
+	Rotate180                 // upside down
+	Rotate270                 // clockwise, the same as 90 degrees anticlockwise
+	FlipHorizontal            // left and right are swapped
+	FlipVertical              // top and bottom are swapped
+	FlipDiagonal              // about the diagonal from a1
+	FlipAntiDiagonal          // about the diagonal from the top right
+)
+
+// the symmetries in the order the canonical board is chosen with, the identity first
+var symmetries = []Symmetry{Identity, Rotate90, Rotate180, Rotate270, FlipHorizontal, FlipVertical, FlipDiagonal, FlipAntiDiagonal}
+
+func (s Symmetry) String() string {
+	switch s {
+	case Identity:
+		return "identity"
+	case Rotate90:
+		return "rotate 90"
+	case Rotate180:
+		return "rotate 180"
+	case Rotate270:
+		return "rotate 270"
+	case FlipHorizontal:
+		return "flip horizontal"
+	case FlipVertical:
+		return "flip vertical"
+	case FlipDiagonal:
+		return "flip diagonal"
+	case FlipAntiDiagonal:
+		return "flip anti-diagonal"
+	}
+	return fmt.Sprintf("Symmetry(%d)", int(s))
+}
+
+// Inverse returns the symmetry that undoes s
+func (s Symmetry) Inverse() Symmetry {
+	switch s {
+	case Rotate90:
+		return Rotate270
+	case Rotate270:
+		return Rotate90
+	}
+	return s
+}
+
+// swapsSides tells that the width and the height are swapped, e.g., 10x6 becomes 6x10
+func (s Symmetry) swapsSides() bool {
+	return s == Rotate90 || s == Rotate270 || s == FlipDiagonal || s == FlipAntiDiagonal
+}
+
+// Size returns the width and the height of the board of w x h after the symmetry
+func (s Symmetry) Size(w, h int) (int, int) {
+	if s.swapsSides() {
+		return h, w
+	}
+	return w, h
+}
+
+// Position returns where p of the board of w x h goes
+func (s Symmetry) Position(p Position, w, h int) Position {
+	switch s {
+	case Rotate90:
+		return Position{h - 1 - p.Y, p.X}
+	case Rotate180:
+		return Position{w - 1 - p.X, h - 1 - p.Y}
+	case Rotate270:
+		return Position{p.Y, w - 1 - p.X}
+	case FlipHorizontal:
+		return Position{w - 1 - p.X, p.Y}
+	case FlipVertical:
+		return Position{p.X, h - 1 - p.Y}
+	case FlipDiagonal:
+		return Position{p.Y, p.X}
+	case FlipAntiDiagonal:
+		return Position{h - 1 - p.Y, w - 1 - p.X}
+	}
+	return p
+}
+
+// Move returns the move on the board of w x h after the symmetry. A pass stays a pass
+func (s Symmetry) Move(m Move, w, h int) Move {
+	if !m.Pass {
+		m.Position = s.Position(m.Position, w, h)
+	}
+	return m
+}
+
+// boardSymmetries returns the symmetries that keep the shape of the board: all of them on a square board,
+// and only the ones that don't swap the sides on a rectangular board
+func boardSymmetries(w, h int) []Symmetry {
+	if w == h {
+		return symmetries
+	}
+
+	kept := make([]Symmetry, 0, len(symmetries)/2)
+	for _, s := range symmetries {
+		if !s.swapsSides() {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// transformRows returns the board rows of w x h after the symmetry
+func transformRows(rows []string, w, h int, s Symmetry) []string {
+	tw, th := s.Size(w, h)
+
+	cells := make([][]byte, th)
+	for y := range cells {
+		cells[y] = make([]byte, tw)
+	}
+
+	for y, row := range rows {
+		for x := 0; x < w; x++ {
+			p := s.Position(Position{x, y}, w, h)
+			cells[p.Y][p.X] = row[x]
+		}
+	}
+
+	transformed := make([]string, th)
+	for y, row := range cells {
+		transformed[y] = string(row)
+	}
+	return transformed
+}
+
+// Transform returns the board after the symmetry, with the same player to move, rules and discs left to set up.
+// The holes are moved with the discs
+func Transform(b *Board, s Symmetry) *Board {
+	rows := transformRows(boardRows(b), b.W, b.H, s)
+
+	// the rows of a board are always valid
+	transformed, _ := parseBoardRows(strings.Join(rows, "/"), b.Turn)
+	transformed.Variant.Rule = b.Variant.Rule
+	transformed.Variant.Opening = b.Variant.Opening
+	transformed.setupLeft = b.setupLeft
+
+	return transformed
+}
+
+// positionKey identifies the position with the player to move
+func positionKey(b *Board) string {
+	return strings.Join(boardRows(b), "/") + " " + colourName(b.Turn)
+}
+
+// variantKey tells the rule and the discs left to set up, as the same discs are another position with them
+func variantKey(b *Board) string {
+	key := ""
+	if b.Variant.Rule == RuleAnti {
+		key += " " + string(RuleAnti)
+	}
+
+	if b.InSetup() {
+		key += fmt.Sprintf(" setup %d", b.setupLeft)
+	}
+
+	return key
+}
+
+// canonical returns the key of the canonical board and the symmetry to it, without making the board
+func canonical(b *Board) (string, Symmetry) {
+	rows := boardRows(b)
+	turn := " " + colourName(b.Turn) + variantKey(b)
+
+	var best string
+	bestSymmetry := Identity
+
+	for _, s := range boardSymmetries(b.W, b.H) {
+		key := strings.Join(transformRows(rows, b.W, b.H, s), "/") + turn
+		if s == Identity || key < best {
+			best, bestSymmetry = key, s
+		}
+	}
+
+	return best, bestSymmetry
+}
+
+// Canonical returns the representative of the board and its symmetries, and the symmetry from the board to it.
+// The symmetrical boards have the same canonical board, so it's used to find the same position seen from another side.
+// The representative is the one with the smallest key, which is positionKey followed by variantKey with the rule and the setup.
+// The earliest symmetry wins if some keys are the same
+func Canonical(b *Board) (*Board, Symmetry) {
+	_, s := canonical(b)
+	return Transform(b, s), s
+}
+
+// canonicalKey is positionKey of the canonical board with variantKey, the same for the symmetrical boards
+func canonicalKey(b *Board) string {
+	key, _ := canonical(b)
+	return key
+}
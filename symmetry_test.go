@@ -0,0 +1,138 @@
+package main
+
+import (
+	"log/slog"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// randomBoard plays random moves from the board
+func randomBoard(b *Board, plies int, rng *rand.Rand) *Board {
+	for i := 0; i < plies && b.HasLegalMove(b.Turn); i++ {
+		legal := legalPositions(b)
+		b, _ = b.Place(legal[rng.Intn(len(legal))])
+	}
+	return b
+}
+
+func TestTransformPosition(t *testing.T) {
+	// a1 of a board 4 wide and 3 high
+	p := Position{0, 0}
+
+	assert.Equal(t, Position{2, 0}, Rotate90.Position(p, 4, 3))
+	assert.Equal(t, Position{3, 2}, Rotate180.Position(p, 4, 3))
+	assert.Equal(t, Position{0, 3}, Rotate270.Position(p, 4, 3))
+	assert.Equal(t, Position{3, 0}, FlipHorizontal.Position(p, 4, 3))
+	assert.Equal(t, Position{0, 2}, FlipVertical.Position(p, 4, 3))
+	assert.Equal(t, Position{0, 0}, FlipDiagonal.Position(p, 4, 3))
+	assert.Equal(t, Position{2, 3}, FlipAntiDiagonal.Position(p, 4, 3))
+
+	for _, s := range symmetries {
+		w, h := s.Size(4, 3)
+		assert.Equal(t, Position{1, 2}, s.Inverse().Position(s.Position(Position{1, 2}, 4, 3), w, h), s.String())
+	}
+
+	pass := Move{Colour: White, Pass: true}
+	assert.Equal(t, pass, Rotate90.Move(pass, 8, 8))
+	assert.Equal(t, Move{Colour: Black, Position: Position{7, 3}}, FlipHorizontal.Move(Move{Colour: Black, Position: Position{0, 3}}, 8, 8))
+}
+
+func TestTransformBoard(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b, _ := parseBoardRows("X---/-XO-/-OO-/---#", White)
+
+	assert.Equal(t, []string{"---X", "-OX-", "-OO-", "#---"}, boardRows(Transform(b, FlipHorizontal)))
+	assert.Equal(t, []string{"---X", "-OX-", "-OO-", "#---"}, boardRows(Transform(b, Rotate90)))
+	assert.Equal(t, White, Transform(b, Rotate90).Turn)
+	assert.True(t, Transform(b, Rotate90).IsHole(Position{0, 3}))
+
+	rect := NewRectBoard(6, 4, Variant{Rule: RuleAnti})
+	rotated := Transform(rect, Rotate90)
+	assert.Equal(t, 4, rotated.W)
+	assert.Equal(t, 6, rotated.H)
+	assert.Equal(t, RuleAnti, rotated.Variant.Rule)
+}
+
+// TestTransformPlace checks that a move and its transform make the same board
+func TestTransformPlace(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	rng := rand.New(rand.NewSource(1))
+
+	for _, size := range [][2]int{{8, 8}, {6, 4}} {
+		b := randomBoard(NewRectBoard(size[0], size[1], Variant{}), 6, rng)
+
+		for _, s := range symmetries {
+			transformed := Transform(b, s)
+
+			for _, p := range legalPositions(b) {
+				placed, _ := b.Place(p)
+
+				moved, err := transformed.Place(s.Position(p, b.W, b.H))
+				assert.NoError(t, err, "%s %s", s, p)
+				assert.Equal(t, boardRows(Transform(placed, s)), boardRows(moved), "%s %s", s, p)
+			}
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	rng := rand.New(rand.NewSource(1))
+
+	boards := []*Board{
+		NewBoard(8),
+		randomBoard(NewBoard(8), 10, rng),
+		randomBoard(NewBoard(8), 30, rng),
+		randomBoard(NewBoardWithVariant(6, Variant{Holes: []string{"a2", "f5"}}), 7, rng),
+		randomBoard(NewRectBoard(10, 6, Variant{}), 9, rng),
+	}
+
+	for _, b := range boards {
+		key := canonicalKey(b)
+
+		canonicalBoard, s := Canonical(b)
+		assert.Equal(t, key, positionKey(canonicalBoard))
+		assert.Equal(t, boardRows(canonicalBoard), boardRows(Transform(b, s)))
+
+		// every transform of the board has the same canonical key
+		for _, s := range boardSymmetries(b.W, b.H) {
+			transformed := Transform(b, s)
+			assert.Equal(t, key, canonicalKey(transformed), "%s\n%s", s, b)
+
+			canonicalTransformed, _ := Canonical(transformed)
+			assert.Equal(t, boardRows(canonicalBoard), boardRows(canonicalTransformed))
+		}
+	}
+
+	// only the symmetries that keep the shape on a rectangular board
+	assert.Len(t, boardSymmetries(10, 6), 4)
+	assert.Len(t, boardSymmetries(8, 8), 8)
+
+	// the player to move is part of the key
+	white := NewBoard(8)
+	white.SwitchTurn()
+	assert.NotEqual(t, canonicalKey(NewBoard(8)), canonicalKey(white))
+
+	// so are the rule and the discs left to set up
+	anti := NewBoardWithVariant(8, Variant{Rule: RuleAnti})
+	assert.NotEqual(t, canonicalKey(NewBoard(8)), canonicalKey(anti))
+	assert.Equal(t, canonicalKey(anti), canonicalKey(Transform(anti, Rotate90)))
+
+	free := NewBoardWithVariant(8, Variant{Opening: OpeningFree})
+	placed, _ := free.Place(Position{3, 3})
+	rotated := Transform(placed, Rotate90)
+
+	assert.True(t, rotated.InSetup())
+	assert.Equal(t, canonicalKey(placed), canonicalKey(rotated))
+	assert.NotEqual(t, canonicalKey(placed), canonicalKey(Transform(free, Rotate90)))
+
+	// the setup goes on on the transformed board
+	next, err := rotated.Place(Position{3, 3})
+	assert.NoError(t, err)
+	assert.True(t, next.InSetup())
+}
@@ -0,0 +1,561 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_TOURNAMENT_GAMES = 20
+	DEFAULT_OPENING_PLIES    = 4
+
+	// z value of the 95% confidence interval of the Elo difference
+	eloConfidence = 1.96
+
+	// random openings are chosen again until they differ from the other openings of the pair
+	openingAttempts = 100
+)
+
+// EngineConfig is an AI setting played in a tournament
+type EngineConfig struct {
+	Name     string
+	Engine   string         // ENGINE_ALPHABETA or ENGINE_MCTS
+	Depth    int            // 0 places randomly
+	Weights  Weights        // score of each cell for the evaluation. nil uses the default
+	Patterns PatternWeights // line tables for the evaluation instead of the cell scores, if not nil
+	Threads  int            // root moves searched at the same time
+
+	// for ENGINE_MCTS
+	Iterations  int
+	Exploration float64
+	Playout     PlayoutPolicy
+	TimeLimit   time.Duration
+}
+
+// ParseEngineConfig parses an engine like "level=3", "depth=5", "random", "depth=3,weights=w.json,name=new", "depth=3,patterns=p.json"
+// or "mcts,iterations=3000,playout=random". The spec is the name if it's not given
+func ParseEngineConfig(spec string) (EngineConfig, error) {
+	e := EngineConfig{
+		Name:        spec,
+		Engine:      ENGINE_ALPHABETA,
+		Depth:       aiLevelDepths[DEFAULT_AI_LEVEL],
+		Iterations:  mctsLevelIterations[DEFAULT_AI_LEVEL],
+		Exploration: DEFAULT_EXPLORATION,
+		Playout:     DEFAULT_PLAYOUT,
+	}
+
+	for _, option := range strings.Split(spec, ",") {
+		key, value, _ := strings.Cut(option, "=")
+
+		switch key {
+		case "random":
+			e.Depth = 0
+		case "level":
+			level, err := strconv.Atoi(value)
+			if err != nil || level < MIN_AI_LEVEL || level > MAX_AI_LEVEL {
+				return EngineConfig{}, fmt.Errorf("Engine %q: level must be between %d and %d", spec, MIN_AI_LEVEL, MAX_AI_LEVEL)
+			}
+			e.Depth = aiLevelDepths[level]
+			e.Iterations = mctsLevelIterations[level]
+		case "depth":
+			depth, err := strconv.Atoi(value)
+			if err != nil || depth < 0 {
+				return EngineConfig{}, fmt.Errorf("Engine %q: depth must be 0 or more", spec)
+			}
+			e.Depth = depth
+		case "weights":
+			weights, err := LoadWeights(value)
+			if err != nil {
+				return EngineConfig{}, fmt.Errorf("Engine %q: %w", spec, err)
+			}
+			e.Weights = weights
+		case "patterns":
+			patterns, err := LoadPatternWeights(value)
+			if err != nil {
+				return EngineConfig{}, fmt.Errorf("Engine %q: %w", spec, err)
+			}
+			e.Patterns = patterns
+		case "threads":
+			threads, err := strconv.Atoi(value)
+			if err != nil || threads < 1 {
+				return EngineConfig{}, fmt.Errorf("Engine %q: threads must be 1 or more", spec)
+			}
+			e.Threads = threads
+		case "mcts":
+			e.Engine = ENGINE_MCTS
+		case "iterations":
+			iterations, err := strconv.Atoi(value)
+			if err != nil || iterations < 0 {
+				return EngineConfig{}, fmt.Errorf("Engine %q: iterations must be 0 or more", spec)
+			}
+			e.Iterations = iterations
+		case "exploration":
+			exploration, err := strconv.ParseFloat(value, 64)
+			if err != nil || exploration < 0 {
+				return EngineConfig{}, fmt.Errorf("Engine %q: exploration must be 0 or more", spec)
+			}
+			e.Exploration = exploration
+		case "playout":
+			playout, err := parsePlayoutPolicy(value)
+			if err != nil {
+				return EngineConfig{}, fmt.Errorf("Engine %q: %w", spec, err)
+			}
+			e.Playout = playout
+		case "time":
+			timeLimit, err := time.ParseDuration(value)
+			if err != nil || timeLimit < 0 {
+				return EngineConfig{}, fmt.Errorf("Engine %q: time must be a duration like 500ms", spec)
+			}
+			e.TimeLimit = timeLimit
+		case "name":
+			e.Name = value
+		default:
+			return EngineConfig{}, fmt.Errorf("Engine %q: unknown option %q", spec, key)
+		}
+	}
+
+	return e, nil
+}
+
+// validate checks the engine can play on the board size
+func (e EngineConfig) validate(n int) error {
+	if _, ok := e.Weights[n]; e.Weights != nil && !ok {
+		return fmt.Errorf("Engine %q: the weights have no table for %dx%d", e.Name, n, n)
+	}
+
+	if _, ok := e.Patterns[n]; e.Patterns != nil && !ok {
+		return fmt.Errorf("Engine %q: the patterns have no tables for %dx%d", e.Name, n, n)
+	}
+
+	return nil
+}
+
+func (e EngineConfig) newPlayer(n int) Engine {
+	if e.Engine == ENGINE_MCTS {
+		mp := NewMctsPlayer(n)
+		mp.Iterations = e.Iterations
+		mp.Exploration = e.Exploration
+		mp.Playout = e.Playout
+		mp.TimeLimit = e.TimeLimit
+		return mp
+	}
+
+	ap := AiPlayer{N: n, W: n, H: n, depth: e.Depth, Threads: e.Threads}
+
+	weights, ok := e.Weights[n]
+	if !ok {
+		weights = cellScores(n, n)
+	}
+	ap.calcScoreTable(weights)
+
+	patterns, ok := e.Patterns[n]
+	if !ok {
+		patterns = aiPatterns[n]
+	}
+	if patterns != nil {
+		ap.patterns = newPatternEvaluator(n, patterns)
+	}
+
+	return &ap
+}
+
+// Tournament plays every pair of engines against each other.
+// Each random opening is played twice with the colours swapped
+type Tournament struct {
+	N            int // the games are on N x N boards with the standard rules
+	Engines      []EngineConfig
+	Games        int // games for each pair, an even number
+	OpeningPlies int // random moves before the engines play
+	Parallel     int // games played at the same time
+	Seed         int64
+	RecordDir    string // directory to save the record of each game, if not empty
+}
+
+// GameResult is a finished game of the tournament
+type GameResult struct {
+	Number     int // 1 for the first game
+	Black      int // index of the engine
+	White      int
+	BlackDiscs int
+	WhiteDiscs int
+	Opening    []string
+	Record     Record
+	RecordPath string
+}
+
+// PairResult is the results of engine A against engine B, from the view of A
+type PairResult struct {
+	A, B     int
+	Wins     int
+	Draws    int
+	Losses   int
+	DiscDiff int // total of A's discs minus B's discs
+}
+
+// TournamentResult is the results of every game and every pair
+type TournamentResult struct {
+	Tournament
+	Results []GameResult
+	Pairs   []PairResult
+}
+
+// tournamentGame is a game to play, before it's played
+type tournamentGame struct {
+	number  int
+	black   int
+	white   int
+	opening []Move
+}
+
+// Run plays every game, and calls progress with the number of finished games if not nil
+func (t Tournament) Run(progress func(done, total int)) (TournamentResult, error) {
+	for _, e := range t.Engines {
+		if err := e.validate(t.N); err != nil {
+			return TournamentResult{}, err
+		}
+	}
+
+	if t.RecordDir != "" {
+		if err := os.MkdirAll(t.RecordDir, 0755); err != nil {
+			return TournamentResult{}, fmt.Errorf("Failed to create the record directory: %w", err)
+		}
+	}
+
+	games := t.schedule()
+	results := make([]GameResult, len(games))
+
+	jobs := make(chan tournamentGame)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+	var saveErr error
+
+	for w := 0; w < max(t.Parallel, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for game := range jobs {
+				result := t.play(game)
+
+				err := t.saveRecord(&result)
+
+				mu.Lock()
+				results[game.number-1] = result
+				if err != nil && saveErr == nil {
+					saveErr = err
+				}
+				done++
+				if progress != nil {
+					progress(done, len(games))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, game := range games {
+		jobs <- game
+	}
+	close(jobs)
+	wg.Wait()
+
+	if saveErr != nil {
+		return TournamentResult{}, saveErr
+	}
+
+	return TournamentResult{Tournament: t, Results: results, Pairs: t.pairResults(results)}, nil
+}
+
+// schedule makes the games of every pair. The openings are the same for every seed,
+// and the openings of a pair are not symmetrical to each other if there are enough of them
+func (t Tournament) schedule() []tournamentGame {
+	rng := rand.New(rand.NewSource(t.Seed))
+
+	games := make([]tournamentGame, 0)
+
+	for a := 0; a < len(t.Engines); a++ {
+		for b := a + 1; b < len(t.Engines); b++ {
+			seen := make(map[string]bool)
+
+			for i := 0; i < t.Games/2; i++ {
+				opening := t.uniqueOpening(seen, rng)
+
+				games = append(games,
+					tournamentGame{len(games) + 1, a, b, opening},
+					tournamentGame{len(games) + 2, b, a, opening},
+				)
+			}
+		}
+	}
+
+	return games
+}
+
+// uniqueOpening returns a random opening whose board is not symmetrical to the boards in seen, and adds it to seen.
+// It gives up after openingAttempts, as short openings are few
+func (t Tournament) uniqueOpening(seen map[string]bool, rng *rand.Rand) []Move {
+	var opening []Move
+
+	for attempt := 0; attempt < openingAttempts; attempt++ {
+		b := NewBoard(t.N)
+		opening = randomOpening(b, t.OpeningPlies, rng)
+
+		for _, m := range opening {
+			b, _ = b.Place(m.Position)
+		}
+
+		key := canonicalKey(b)
+		if !seen[key] {
+			seen[key] = true
+			break
+		}
+	}
+
+	return opening
+}
+
+// randomOpening plays random moves from the board.
+// It stops early so that the player to move always has a legal move
+func randomOpening(b *Board, plies int, rng *rand.Rand) []Move {
+	moves := make([]Move, 0, plies)
+
+	for len(moves) < plies && b.HasLegalMove(b.Turn) {
+		p := randomMove(b, rng)
+		placed, _ := b.Place(p)
+
+		if !placed.HasLegalMove(placed.Turn) {
+			break
+		}
+
+		totalB, totalW := placed.Count()
+		moves = append(moves, Move{Colour: b.Turn, Position: p, Black: totalB, White: totalW})
+		b = placed
+	}
+
+	return moves
+}
+
+func randomMove(b *Board, rng *rand.Rand) Position {
+	cells := make([]int, 0, b.CellN)
+	for cell := 0; cell < b.CellN; cell++ {
+		if b.IsLegal(cell, b.Turn) {
+			cells = append(cells, cell)
+		}
+	}
+
+	return cellToPosition(b.W, cells[rng.Intn(len(cells))])
+}
+
+// play plays a game between two AiClients without a display
+func (t Tournament) play(game tournamentGame) GameResult {
+	b := NewBoard(t.N)
+	for _, m := range game.opening {
+		b, _ = b.Place(m.Position)
+	}
+
+	g := NewGame(b, AI, AI)
+	g.Player1.Name = t.Engines[game.black].Name
+	g.Player2.Name = t.Engines[game.white].Name
+	g.Moves = append([]Move{}, game.opening...)
+
+	player1CmdCh, player2CmdCh, player1GameCh, player2GameCh, player1QuitCh, player2QuitCh := g.Start()
+
+	resultCh := make(chan Game)
+
+	cli1 := NewAiClient(t.N, DEFAULT_AI_LEVEL, player1GameCh, player1CmdCh, player1QuitCh, Player1Id)
+	cli1.p = t.Engines[game.black].newPlayer(t.N)
+	cli1.MinDelay = 0
+	cli1.ResultCh = resultCh
+
+	cli2 := NewAiClient(t.N, DEFAULT_AI_LEVEL, player2GameCh, player2CmdCh, player2QuitCh, Player2Id)
+	cli2.p = t.Engines[game.white].newPlayer(t.N)
+	cli2.MinDelay = 0
+
+	go cli1.Run()
+	go cli2.Run()
+
+	finished := <-resultCh
+	player1QuitCh <- true
+
+	totalB, totalW := finished.Board.Count()
+
+	opening := make([]string, 0, len(game.opening))
+	for _, m := range game.opening {
+		opening = append(opening, formatMove(m))
+	}
+
+	return GameResult{
+		Number:     game.number,
+		Black:      game.black,
+		White:      game.white,
+		BlackDiscs: totalB,
+		WhiteDiscs: totalW,
+		Opening:    opening,
+		Record:     finished.Record(),
+	}
+}
+
+func (t Tournament) saveRecord(result *GameResult) error {
+	if t.RecordDir == "" {
+		return nil
+	}
+
+	path := filepath.Join(t.RecordDir, fmt.Sprintf("game-%03d.json", result.Number))
+	if err := result.Record.Save(path); err != nil {
+		return err
+	}
+	result.RecordPath = path
+
+	return nil
+}
+
+func (t Tournament) pairResults(results []GameResult) []PairResult {
+	pairs := make([]PairResult, 0)
+	index := make(map[[2]int]int)
+
+	for a := 0; a < len(t.Engines); a++ {
+		for b := a + 1; b < len(t.Engines); b++ {
+			index[[2]int{a, b}] = len(pairs)
+			pairs = append(pairs, PairResult{A: a, B: b})
+		}
+	}
+
+	for _, r := range results {
+		a, b := min(r.Black, r.White), max(r.Black, r.White)
+		p := &pairs[index[[2]int{a, b}]]
+
+		// discs of A minus discs of B
+		diff := r.BlackDiscs - r.WhiteDiscs
+		if r.Black != a {
+			diff = -diff
+		}
+
+		p.DiscDiff += diff
+		switch {
+		case diff > 0:
+			p.Wins++
+		case diff < 0:
+			p.Losses++
+		default:
+			p.Draws++
+		}
+	}
+
+	return pairs
+}
+
+func (p PairResult) Games() int {
+	return p.Wins + p.Draws + p.Losses
+}
+
+// Score is the ratio of the points of A, counting a draw as a half
+func (p PairResult) Score() float64 {
+	if p.Games() == 0 {
+		return 0.5
+	}
+	return (float64(p.Wins) + float64(p.Draws)/2) / float64(p.Games())
+}
+
+func (p PairResult) AverageDiscDiff() float64 {
+	if p.Games() == 0 {
+		return 0
+	}
+	return float64(p.DiscDiff) / float64(p.Games())
+}
+
+// Elo returns the estimated Elo difference of A over B, and the margin of its 95% confidence interval.
+// The margin is infinite if one engine won every game
+func (p PairResult) Elo() (float64, float64) {
+	n := float64(p.Games())
+	s := p.Score()
+
+	if n == 0 || s <= 0 || s >= 1 {
+		return eloDiff(s), math.Inf(1)
+	}
+
+	// variance of the points of one game
+	variance := (float64(p.Wins)*math.Pow(1-s, 2) +
+		float64(p.Draws)*math.Pow(0.5-s, 2) +
+		float64(p.Losses)*math.Pow(s, 2)) / n
+
+	// the standard error of the score, converted to Elo with the slope of eloDiff at s
+	slope := 400 / (math.Ln10 * s * (1 - s))
+	margin := eloConfidence * math.Sqrt(variance/n) * slope
+
+	return eloDiff(s), margin
+}
+
+// eloDiff converts the expected score to the Elo difference
+func eloDiff(score float64) float64 {
+	if score <= 0 {
+		return math.Inf(-1)
+	}
+	if score >= 1 {
+		return math.Inf(1)
+	}
+	return -400 * math.Log10(1/score-1)
+}
+
+func formatElo(elo float64, margin float64) string {
+	s := fmt.Sprintf("%+.0f", elo)
+	if math.IsInf(elo, 0) {
+		s = strings.ToLower(fmt.Sprintf("%+v", elo))
+	}
+
+	if math.IsInf(margin, 0) {
+		return s + " ± inf"
+	}
+	return fmt.Sprintf("%s ± %.0f", s, margin)
+}
+
+// WriteText writes the results of each pair
+func (r TournamentResult) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Tournament %dx%d, %d games for each pair, %d random opening moves, seed %d\n\n", r.N, r.N, r.Games, r.OpeningPlies, r.Seed)
+
+	fmt.Fprintf(w, "%-30s %4s %4s %4s %7s %9s  %s\n", "Engines", "Win", "Draw", "Loss", "Score", "Disc diff", "Elo")
+	for _, p := range r.Pairs {
+		engines := fmt.Sprintf("%s vs %s", r.Engines[p.A].Name, r.Engines[p.B].Name)
+		fmt.Fprintf(w, "%-30s %4d %4d %4d %6.1f%% %+9.1f  %s\n",
+			engines, p.Wins, p.Draws, p.Losses, p.Score()*100, p.AverageDiscDiff(), formatElo(p.Elo()))
+	}
+}
+
+// WriteCSV writes a row for each game
+func (r TournamentResult) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	writer.Write([]string{"game", "black", "white", "black_discs", "white_discs", "winner", "opening", "record"})
+
+	for _, g := range r.Results {
+		winner := "draw"
+		if g.BlackDiscs > g.WhiteDiscs {
+			winner = r.Engines[g.Black].Name
+		} else if g.BlackDiscs < g.WhiteDiscs {
+			winner = r.Engines[g.White].Name
+		}
+
+		writer.Write([]string{
+			strconv.Itoa(g.Number),
+			r.Engines[g.Black].Name,
+			r.Engines[g.White].Name,
+			strconv.Itoa(g.BlackDiscs),
+			strconv.Itoa(g.WhiteDiscs),
+			winner,
+			strings.Join(g.Opening, " "),
+			g.RecordPath,
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
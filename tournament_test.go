@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEngineConfig(t *testing.T) {
+	weightsPath := filepath.Join(t.TempDir(), "weights.json")
+	os.WriteFile(weightsPath, []byte(`[[1, 2, 3], [4, 5, 6], [7, 8, 9]]`), 0644)
+
+	e, err := ParseEngineConfig("level=3")
+	assert.NoError(t, err)
+	assert.Equal(t, ENGINE_ALPHABETA, e.Engine)
+	assert.Equal(t, 3, e.Depth)
+	assert.Equal(t, mctsLevelIterations[3], e.Iterations)
+
+	e, err = ParseEngineConfig("random")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, e.Depth)
+
+	e, err = ParseEngineConfig("depth=4,weights=" + weightsPath + ",name=tuned")
+	assert.NoError(t, err)
+	assert.Equal(t, "tuned", e.Name)
+	assert.Equal(t, 4, e.Depth)
+	assert.Equal(t, Weights{3: {{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}}, e.Weights)
+	assert.NoError(t, e.validate(3))
+	assert.EqualError(t, e.validate(4), `Engine "tuned": the weights have no table for 4x4`)
+
+	e, err = ParseEngineConfig("mcts,iterations=500,exploration=0.5,playout=random,time=2s")
+	assert.NoError(t, err)
+	assert.Equal(t, ENGINE_MCTS, e.Engine)
+	mp := e.newPlayer(6).(*MctsPlayer)
+	assert.Equal(t, 500, mp.Iterations)
+	assert.Equal(t, 0.5, mp.Exploration)
+	assert.Equal(t, PlayoutRandom, mp.Playout)
+	assert.Equal(t, 2*time.Second, mp.TimeLimit)
+
+	for _, spec := range []string{"level=6", "depth=-1", "depth=x", "speed=1", "weights=missing.json", "mcts,iterations=-1", "mcts,playout=smart", "mcts,time=1"} {
+		_, err := ParseEngineConfig(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestEngineConfigWeights(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	b := NewBoard(4)
+
+	// only the black disc on b2 counts
+	weights := Weights{4: {
+		{0, 0, 0, 0},
+		{0, 1, 0, 0},
+		{0, 0, 0, 0},
+		{0, 0, 0, 0},
+	}}
+
+	ap := EngineConfig{Depth: 1, Weights: weights}.newPlayer(4).(*AiPlayer)
+	assert.Equal(t, 1, ap.evaluate(b))
+
+	// the default weights are symmetric
+	ap = EngineConfig{Depth: 1}.newPlayer(4).(*AiPlayer)
+	assert.Equal(t, 0, ap.evaluate(b))
+}
+
+func TestPairResultElo(t *testing.T) {
+	even := PairResult{Wins: 5, Draws: 2, Losses: 5}
+	elo, margin := even.Elo()
+	assert.Equal(t, 0.0, elo)
+	assert.InDelta(t, 179.5, margin, 0.1)
+
+	better := PairResult{Wins: 15, Draws: 0, Losses: 5}
+	elo, margin = better.Elo()
+	assert.InDelta(t, 190.8, elo, 0.1)
+	assert.Greater(t, margin, 0.0)
+
+	// more games, smaller margin
+	_, largerMargin := PairResult{Wins: 3, Draws: 0, Losses: 1}.Elo()
+	assert.Greater(t, largerMargin, margin)
+
+	elo, margin = PairResult{Wins: 4}.Elo()
+	assert.True(t, math.IsInf(elo, 1))
+	assert.True(t, math.IsInf(margin, 1))
+	assert.Equal(t, "+inf ± inf", formatElo(elo, margin))
+	assert.Equal(t, "-191 ± 12", formatElo(-190.8, 12.3))
+}
+
+func TestRandomOpening(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	opening1 := randomOpening(NewBoard(8), 6, rand.New(rand.NewSource(1)))
+	opening2 := randomOpening(NewBoard(8), 6, rand.New(rand.NewSource(1)))
+
+	assert.Len(t, opening1, 6)
+	assert.Equal(t, opening1, opening2)
+
+	// the moves are legal
+	r := Record{N: 8}
+	for _, m := range opening1 {
+		r.Moves = append(r.Moves, formatMove(m))
+	}
+	_, _, err := r.Positions()
+	assert.NoError(t, err)
+}
+
+func TestRandomMoveOnRectBoard(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		b := NewRectBoard(4, 8, Variant{})
+		for b.HasLegalMove(b.Turn) {
+			p := randomMove(b, rng)
+			if !assert.True(t, b.IsLegal(p.X+p.Y*b.W, b.Turn)) {
+				return
+			}
+
+			b, _ = b.Place(p)
+		}
+	}
+}
+
+func TestTournamentSchedule(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	tournament := Tournament{
+		N:            6,
+		Engines:      []EngineConfig{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+		Games:        4,
+		OpeningPlies: 2,
+		Seed:         1,
+	}
+
+	games := tournament.schedule()
+
+	// 3 pairs
+	assert.Len(t, games, 12)
+
+	for i := 0; i < len(games); i += 2 {
+		assert.Equal(t, i+1, games[i].number)
+		assert.Equal(t, games[i].black, games[i+1].white)
+		assert.Equal(t, games[i].white, games[i+1].black)
+		assert.Equal(t, games[i].opening, games[i+1].opening)
+	}
+
+	// the openings of a pair are not symmetrical
+	for i := 0; i < len(games); i += 4 {
+		assert.NotEqual(t, openingKey(6, games[i].opening), openingKey(6, games[i+2].opening))
+	}
+}
+
+// openingKey returns the canonicalKey of the board after the opening
+func openingKey(n int, opening []Move) string {
+	b := NewBoard(n)
+	for _, m := range opening {
+		b, _ = b.Place(m.Position)
+	}
+	return canonicalKey(b)
+}
+
+func TestTournamentRun(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	dir := t.TempDir()
+
+	tournament := Tournament{
+		N:            6,
+		Engines:      []EngineConfig{{Name: "depth=2", Depth: 2}, {Name: "random", Depth: 0}},
+		Games:        4,
+		OpeningPlies: 2,
+		Parallel:     2,
+		Seed:         1,
+		RecordDir:    dir,
+	}
+
+	result, err := tournament.Run(nil)
+	assert.NoError(t, err)
+
+	assert.Len(t, result.Results, 4)
+	assert.Len(t, result.Pairs, 1)
+
+	p := result.Pairs[0]
+	assert.Equal(t, 4, p.Games())
+
+	for _, g := range result.Results {
+		// every game is played to the end
+		r, err := LoadRecord(g.RecordPath)
+		assert.NoError(t, err)
+
+		boards, _, err := r.Positions()
+		assert.NoError(t, err)
+
+		last := boards[len(boards)-1]
+		assert.False(t, last.HasLegalMove(Black) || last.HasLegalMove(White))
+
+		totalB, totalW := last.Count()
+		assert.Equal(t, g.BlackDiscs, totalB)
+		assert.Equal(t, g.WhiteDiscs, totalW)
+		assert.Equal(t, tournament.Engines[g.Black].Name, r.Black)
+		assert.Equal(t, g.Opening, r.Moves[:len(g.Opening)])
+	}
+
+	var text bytes.Buffer
+	result.WriteText(&text)
+	assert.Contains(t, text.String(), "depth=2 vs random")
+
+	var csv bytes.Buffer
+	assert.NoError(t, result.WriteCSV(&csv))
+
+	lines := strings.Split(strings.TrimSpace(csv.String()), "\n")
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "game,black,white,black_discs,white_discs,winner,opening,record", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "1,depth=2,random,"))
+}
+
+func TestTournamentRunInvalidWeights(t *testing.T) {
+	tournament := Tournament{
+		N:       6,
+		Engines: []EngineConfig{{Name: "a", Weights: Weights{3: {{1, 1, 1}, {1, 1, 1}, {1, 1, 1}}}}, {Name: "b"}},
+		Games:   2,
+	}
+
+	_, err := tournament.Run(nil)
+	assert.EqualError(t, err, `Engine "a": the weights have no table for 6x6`)
+}
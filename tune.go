@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_TUNE_GAMES    = 200
+	DEFAULT_TUNE_DEPTH    = 2
+	DEFAULT_SOLVE_EMPTIES = 8
+	DEFAULT_RIDGE         = 1.0
+
+	// the rate of random moves in self-play, so that the games don't repeat
+	tuneRandomMoveRate = 0.1
+
+	// the tuned scores are in tenths of a disc
+	weightScale = 10
+)
+
+// TuneSample is a position labelled with the disc difference at the end of the game
+type TuneSample struct {
+	Board  *Board
+	Label  float64 // from the view of the player to move
+	Solved bool    // the label is the result of the perfect play, not of the game
+}
+
+// Tuner fits the cell scores of the evaluation to the results of games
+type Tuner struct {
+	N            int // the scores are for N x N boards with the standard rules
+	Games        int // self-play games
+	Depth        int // search depth of the self-play
+	OpeningPlies int // random moves at the start of each self-play game
+	SolveEmpties int // positions with this number of empty cells or fewer are solved to the end
+	Ridge        float64
+	Seed         int64
+
+	// learn the line tables instead of the cell scores
+	Patterns bool
+	Phases   int
+	Epochs   int
+	Rate     float64
+}
+
+// TuneResult is the fitted cell scores
+type TuneResult struct {
+	Table   [][]int
+	Classes []float64 // the weight of each group of symmetric cells, in discs
+	Samples int
+	Solved  int
+	RMSE    float64 // error of the fitted evaluation, in discs
+}
+
+// SelfPlay plays the games for tuning. Games are the same for the same seed
+func (t Tuner) SelfPlay() []Record {
+	records := make([]Record, 0, t.Games)
+
+	ap := NewAiPlayer(t.N)
+	ap.depth = t.Depth
+
+	for i := 0; i < t.Games; i++ {
+		rng := rand.New(rand.NewSource(t.Seed + int64(i)))
+
+		r := Record{N: t.N, Black: "self-play", White: "self-play"}
+
+		b := NewBoard(t.N)
+		for _, m := range randomOpening(b, t.OpeningPlies, rng) {
+			b, _ = b.Place(m.Position)
+			r.Moves = append(r.Moves, formatMove(m))
+		}
+
+		for {
+			if !b.HasLegalMove(b.Turn) {
+				if !b.HasLegalMove(!b.Turn) {
+					break
+				}
+
+				b = b.CopyBoard()
+				b.SwitchTurn()
+				r.Moves = append(r.Moves, PassString)
+				continue
+			}
+
+			var p Position
+			if rng.Float64() < tuneRandomMoveRate {
+				p = randomMove(b, rng)
+			} else {
+				ap.Colour = b.Turn
+				p, _ = ap.getBest(b)
+			}
+
+			b, _ = b.Place(p)
+			r.Moves = append(r.Moves, p.String())
+		}
+
+		records = append(records, r)
+	}
+
+	return records
+}
+
+// Samples labels every position of the records where the player to move has a legal move.
+// The same positions, also the symmetrical ones, are merged with the average label
+func (t Tuner) Samples(records []Record) ([]TuneSample, error) {
+	samples := make([]TuneSample, 0)
+	index := make(map[string]int)
+	counts := make([]int, 0)
+
+	for i, r := range records {
+		if w, h := r.sides(); w != t.N || h != t.N {
+			return nil, fmt.Errorf("Record %d is %dx%d, not %dx%d", i+1, w, h, t.N, t.N)
+		}
+		if !r.Variant.isStandard() {
+			return nil, fmt.Errorf("Record %d is not the standard rules: %s", i+1, r.Variant)
+		}
+
+		boards, _, err := r.Positions()
+		if err != nil {
+			return nil, fmt.Errorf("Record %d: %w", i+1, err)
+		}
+
+		last := boards[len(boards)-1]
+		if last.HasLegalMove(Black) || last.HasLegalMove(White) {
+			return nil, fmt.Errorf("Record %d is not finished", i+1)
+		}
+
+		totalB, totalW := last.Count()
+		result := float64(totalB - totalW)
+
+		for _, b := range boards {
+			if !b.HasLegalMove(b.Turn) {
+				continue
+			}
+
+			label := result
+			if b.Turn == White {
+				label = -label
+			}
+
+			key := canonicalKey(b)
+			if j, ok := index[key]; ok {
+				samples[j].Label += label
+				counts[j]++
+				continue
+			}
+
+			index[key] = len(samples)
+			samples = append(samples, TuneSample{Board: b, Label: label})
+			counts = append(counts, 1)
+		}
+	}
+
+	for j := range samples {
+		samples[j].Label /= float64(counts[j])
+	}
+
+	t.solve(samples)
+
+	return samples, nil
+}
+
+// solve replaces the labels of the positions near the end with the results of the perfect play
+func (t Tuner) solve(samples []TuneSample) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < runtime.NumCPU(); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ap := NewAiPlayer(t.N)
+			for i := range jobs {
+				b := samples[i].Board
+				score, _, _ := ap.search(b, b.CountEmptyCells(), true, time.Time{})
+
+				samples[i].Label = float64(score)
+				samples[i].Solved = true
+			}
+		}()
+	}
+
+	for i, s := range samples {
+		if s.Board.CountEmptyCells() <= t.SolveEmpties {
+			jobs <- i
+		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// Fit finds the weight of each group of symmetric cells by ridge regression,
+// so that the evaluation of a position is close to its label
+func (t Tuner) Fit(samples []TuneSample) TuneResult {
+	classes, classN := cellClasses(t.N)
+
+	// normal equations (X^T X + ridge I) w = X^T y
+	a := make([][]float64, classN)
+	for i := range a {
+		a[i] = make([]float64, classN)
+		a[i][i] = t.Ridge
+	}
+	y := make([]float64, classN)
+
+	features := make([][]float64, len(samples))
+	solved := 0
+
+	for k, s := range samples {
+		x := sampleFeatures(s.Board, classes, classN)
+		features[k] = x
+
+		for i := range x {
+			y[i] += x[i] * s.Label
+			for j := range x {
+				a[i][j] += x[i] * x[j]
+			}
+		}
+
+		if s.Solved {
+			solved++
+		}
+	}
+
+	w := solveLinear(a, y)
+
+	sumSquares := 0.0
+	for k, s := range samples {
+		predicted := 0.0
+		for i, x := range features[k] {
+			predicted += w[i] * x
+		}
+		sumSquares += math.Pow(predicted-s.Label, 2)
+	}
+
+	table := make([][]int, t.N)
+	for y := range table {
+		table[y] = make([]int, t.N)
+		for x := range table[y] {
+			table[y][x] = int(math.Round(w[classes[x+y*t.N]] * weightScale))
+		}
+	}
+
+	result := TuneResult{Table: table, Classes: w, Samples: len(samples), Solved: solved}
+	if len(samples) > 0 {
+		result.RMSE = math.Sqrt(sumSquares / float64(len(samples)))
+	}
+
+	return result
+}
+
+// sampleFeatures counts the discs of each group of cells, 1 for the player to move and -1 for the opponent
+func sampleFeatures(b *Board, classes []int, classN int) []float64 {
+	x := make([]float64, classN)
+
+	own, opponent := HasBlack, HasWhite
+	if b.Turn == White {
+		own, opponent = HasWhite, HasBlack
+	}
+
+	for row := 0; row < b.N; row++ {
+		idx := b.Lines[LineId(row)]
+		for col := 0; col < b.N; col++ {
+			switch idx.GetLocalState(col) {
+			case own:
+				x[classes[col+row*b.N]]++
+			case opponent:
+				x[classes[col+row*b.N]]--
+			}
+		}
+	}
+
+	return x
+}
+
+// cellClasses groups the cells that are the same by rotation and reflection.
+// It returns the group of each cell and the number of groups
+func cellClasses(n int) ([]int, int) {
+	classes := make([]int, n*n)
+	ids := make(map[int]int)
+
+	for cell := range classes {
+		x, y := cell%n, cell/n
+
+		// the smallest cell of the 8 symmetric cells represents the group
+		smallest := cell
+		for _, p := range [][2]int{
+			{n - 1 - x, y}, {x, n - 1 - y}, {n - 1 - x, n - 1 - y},
+			{y, x}, {n - 1 - y, x}, {y, n - 1 - x}, {n - 1 - y, n - 1 - x},
+		} {
+			smallest = min(smallest, p[0]+p[1]*n)
+		}
+
+		id, ok := ids[smallest]
+		if !ok {
+			id = len(ids)
+			ids[smallest] = id
+		}
+		classes[cell] = id
+	}
+
+	return classes, len(ids)
+}
+
+// solveLinear solves a x = b by Gaussian elimination with partial pivoting
+func solveLinear(a [][]float64, b []float64) []float64 {
+	n := len(b)
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+
+		if a[col][col] == 0 {
+			continue
+		}
+
+		for row := col + 1; row < n; row++ {
+			factor := a[row][col] / a[col][col]
+			for k := col; k < n; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+			b[row] -= factor * b[col]
+		}
+	}
+
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		if a[row][row] == 0 {
+			continue
+		}
+
+		sum := b[row]
+		for k := row + 1; k < n; k++ {
+			sum -= a[row][k] * x[k]
+		}
+		x[row] = sum / a[row][row]
+	}
+
+	return x
+}
+
+// WriteText writes the fitted table
+func (r TuneResult) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "%d positions, %d solved to the end\n", r.Samples, r.Solved)
+	fmt.Fprintf(w, "RMSE %.2f discs\n\n", r.RMSE)
+
+	for _, row := range r.Table {
+		cells := make([]string, 0, len(row))
+		for _, score := range row {
+			cells = append(cells, fmt.Sprintf("%4d", score))
+		}
+		fmt.Fprintln(w, strings.Join(cells, " "))
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCellClasses(t *testing.T) {
+	classes, n := cellClasses(4)
+
+	assert.Equal(t, 3, n)
+	assert.Equal(t, []int{
+		0, 1, 1, 0,
+		1, 2, 2, 1,
+		1, 2, 2, 1,
+		0, 1, 1, 0,
+	}, classes)
+
+	_, n = cellClasses(6)
+	assert.Equal(t, 6, n)
+
+	_, n = cellClasses(8)
+	assert.Equal(t, 10, n)
+}
+
+func TestSolveLinear(t *testing.T) {
+	// 2x + y = 5, x + 3y = 10
+	x := solveLinear([][]float64{{2, 1}, {1, 3}}, []float64{5, 10})
+
+	assert.InDelta(t, 1, x[0], 1e-9)
+	assert.InDelta(t, 3, x[1], 1e-9)
+}
+
+func TestTunerFitFindsWeights(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	tuner := Tuner{N: 4, Ridge: 0}
+
+	records := playFirstLegalMoves(4)
+	boards, _, err := records.Positions()
+	assert.NoError(t, err)
+
+	// label the positions with known weights: corner 5, edge -1, centre 2
+	classes, classN := cellClasses(4)
+	want := []float64{5, -1, 2}
+
+	samples := make([]TuneSample, 0)
+	for _, b := range boards {
+		label := 0.0
+		for i, x := range sampleFeatures(b, classes, classN) {
+			label += want[i] * x
+		}
+		samples = append(samples, TuneSample{Board: b, Label: label})
+	}
+
+	result := tuner.Fit(samples)
+
+	for i := range want {
+		assert.InDelta(t, want[i], result.Classes[i], 1e-6)
+	}
+	assert.InDelta(t, 0, result.RMSE, 1e-6)
+	assert.Equal(t, []int{50, -10, -10, 50}, result.Table[0])
+	assert.Equal(t, []int{-10, 20, 20, -10}, result.Table[1])
+}
+
+func TestTunerSamples(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	tuner := Tuner{N: 4, SolveEmpties: 4}
+
+	r := playFirstLegalMoves(4)
+
+	samples, err := tuner.Samples([]Record{r, r})
+	assert.NoError(t, err)
+
+	boards, _, _ := r.Positions()
+	last := boards[len(boards)-1]
+	totalB, totalW := last.Count()
+
+	for _, s := range samples {
+		if s.Board.CountEmptyCells() <= 4 {
+			assert.True(t, s.Solved)
+			continue
+		}
+
+		// the same record twice has the same positions, which are merged
+		want := float64(totalB - totalW)
+		if s.Board.Turn == White {
+			want = -want
+		}
+		assert.False(t, s.Solved)
+		assert.Equal(t, want, s.Label)
+	}
+
+	assert.LessOrEqual(t, len(samples), len(boards))
+
+	_, err = Tuner{N: 6}.Samples([]Record{r})
+	assert.EqualError(t, err, "Record 1 is 4x4, not 6x6")
+
+	_, err = tuner.Samples([]Record{{N: 4, Moves: r.Moves[:3]}})
+	assert.EqualError(t, err, "Record 1 is not finished")
+
+	// the weights are for the standard rules
+	anti := r
+	anti.Variant = Variant{Rule: RuleAnti}
+	_, err = tuner.Samples([]Record{anti})
+	assert.EqualError(t, err, "Record 1 is not the standard rules: Anti-reversi")
+}
+
+func TestTunerSamplesMergeSymmetries(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	tuner := Tuner{N: 4}
+
+	r := playFirstLegalMoves(4)
+
+	// the same game reflected on the diagonal, which keeps the start
+	rotated := Record{N: 4, Moves: make([]string, 0, len(r.Moves))}
+	for _, m := range r.Moves {
+		if m == PassString {
+			rotated.Moves = append(rotated.Moves, m)
+			continue
+		}
+		p, _ := parseCoordinate(m)
+		rotated.Moves = append(rotated.Moves, FlipDiagonal.Position(p, 4, 4).String())
+	}
+
+	samples, err := tuner.Samples([]Record{r})
+	assert.NoError(t, err)
+
+	merged, err := tuner.Samples([]Record{r, rotated})
+	assert.NoError(t, err)
+	assert.Len(t, merged, len(samples))
+}
+
+func TestTunerSolvedLabel(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	// black takes everything with d1
+	b, err := parseBoardRows("XOO-/----/----/----", Black)
+	assert.NoError(t, err)
+
+	samples := []TuneSample{{Board: b, Label: 0}}
+	Tuner{N: 4, SolveEmpties: 16}.solve(samples)
+
+	assert.True(t, samples[0].Solved)
+	assert.Equal(t, 4.0, samples[0].Label)
+}
+
+func TestTunerDeterministic6x6(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	tuner := Tuner{N: 6, Games: 40, Depth: 1, OpeningPlies: 4, SolveEmpties: 6, Ridge: 1, Seed: 1}
+
+	run := func() TuneResult {
+		samples, err := tuner.Samples(tuner.SelfPlay())
+		assert.NoError(t, err)
+		return tuner.Fit(samples)
+	}
+
+	result := run()
+
+	assert.Equal(t, run(), result)
+	assert.Greater(t, result.Solved, 0)
+	assert.Greater(t, result.Samples, result.Solved)
+
+	// the table is symmetric
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 6; x++ {
+			assert.Equal(t, result.Table[y][x], result.Table[x][y])
+			assert.Equal(t, result.Table[y][x], result.Table[y][5-x])
+		}
+	}
+
+	// corners are the best cells
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 6; x++ {
+			assert.LessOrEqual(t, result.Table[y][x], result.Table[0][0])
+		}
+	}
+}
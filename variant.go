@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+type Rule string
+
+const (
+	RuleStandard Rule = "standard" // the player with more discs wins
+	RuleAnti     Rule = "anti"     // the player with fewer discs wins
+)
+
+type Opening string
+
+const (
+	OpeningStandard Opening = "standard" // the diagonal cross
+	OpeningParallel Opening = "parallel" // the discs of the same colour side by side
+	OpeningRandom   Opening = "random"   // one of the starts of two black and two white discs, chosen randomly
+	OpeningFree     Opening = "free"     // the players place the first four discs on the centre
+)
+
+// Variant is the rules of the game. It's saved in the record, so that the game can be replayed
+type Variant struct {
+	Rule    Rule     `json:"rule,omitempty"`
+	Opening Opening  `json:"opening,omitempty"`
+	Start   string   `json:"start,omitempty"` // the centre discs at the start row by row, e.g., "bwwb". Empty in the free opening
+	Holes   []string `json:"holes,omitempty"` // the cells no one can place on, e.g., ["a3", "h6"]
+
+	// the position the game starts from in the position notation, instead of the opening
+	Position string `json:"position,omitempty"`
+}
+
+// the starts the random opening chooses from. Every one has two black and two white discs on the centre
+var randomStarts = []string{"bwwb", "wbbw", "bbww", "wwbb", "bwbw", "wbwb"}
+
+// openingStart returns the centre discs of the opening
+func openingStart(o Opening) string {
+	switch o {
+	case OpeningParallel:
+		return "bbww"
+	case OpeningRandom:
+		return randomStarts[rand.Intn(len(randomStarts))]
+	}
+	return "bwwb"
+}
+
+func parseRule(s string) (Rule, error) {
+	switch Rule(s) {
+	case RuleStandard, RuleAnti:
+		return Rule(s), nil
+	}
+	return "", fmt.Errorf("Unknown rule %q: it must be %s or %s", s, RuleStandard, RuleAnti)
+}
+
+func parseOpening(s string) (Opening, error) {
+	switch Opening(s) {
+	case OpeningStandard, OpeningParallel, OpeningRandom, OpeningFree:
+		return Opening(s), nil
+	}
+	return "", fmt.Errorf("Unknown opening %q: it must be %s, %s, %s or %s", s, OpeningStandard, OpeningParallel, OpeningRandom, OpeningFree)
+}
+
+// parseVariant parses the rule and the opening of the flags
+func parseVariant(rule, opening string) (Variant, error) {
+	r, err := parseRule(rule)
+	if err != nil {
+		return Variant{}, err
+	}
+
+	o, err := parseOpening(opening)
+	if err != nil {
+		return Variant{}, err
+	}
+
+	return Variant{Rule: r, Opening: o}, nil
+}
+
+// validate checks the variant read from a record for the board of w x h. Empty fields are the standard rules
+func (v Variant) validate(w, h int) error {
+	if v.Rule != "" {
+		if _, err := parseRule(string(v.Rule)); err != nil {
+			return err
+		}
+	}
+
+	if v.Opening != "" {
+		if _, err := parseOpening(string(v.Opening)); err != nil {
+			return err
+		}
+	}
+
+	if v.Position != "" {
+		return v.validatePosition(w, h)
+	}
+
+	if err := validateHoles(w, h, v.Holes); err != nil {
+		return err
+	}
+
+	if v.Start == "" {
+		return nil
+	}
+
+	if v.Opening == OpeningFree {
+		return fmt.Errorf("The free opening has no start: %q", v.Start)
+	}
+
+	for _, start := range randomStarts {
+		if v.Start == start {
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown start %q: it must be 2 b and 2 w like %s", v.Start, randomStarts[0])
+}
+
+func (v Variant) isStandard() bool {
+	return (v.Rule == "" || v.Rule == RuleStandard) && (v.Opening == "" || v.Opening == OpeningStandard) && len(v.Holes) == 0 && v.Position == ""
+}
+
+// String describes the variant for the game info, empty for the standard rules
+func (v Variant) String() string {
+	s := ""
+	if v.Rule == RuleAnti {
+		s = "Anti-reversi"
+	}
+
+	if v.Opening != "" && v.Opening != OpeningStandard {
+		if s != "" {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s opening", v.Opening)
+	}
+
+	if v.Position != "" {
+		if s != "" {
+			s += ", "
+		}
+		s += "custom position"
+	}
+
+	if len(v.Holes) > 0 {
+		if s != "" {
+			s += ", "
+		}
+		s += fmt.Sprintf("%d holes", len(v.Holes))
+	}
+
+	return s
+}
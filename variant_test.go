@@ -0,0 +1,171 @@
+package main
+
+import (
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBoardWithVariantOpenings(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	standard := NewBoardWithVariant(8, Variant{Opening: OpeningStandard})
+	assert.Equal(t, NewBoard(8).Lines, standard.Lines)
+	assert.Equal(t, Variant{Rule: RuleStandard, Opening: OpeningStandard, Start: "bwwb"}, standard.Variant)
+
+	parallel := NewBoardWithVariant(6, Variant{Opening: OpeningParallel})
+	assert.Equal(t, []string{"------", "------", "--XX--", "--OO--", "------", "------"}, boardRows(parallel))
+	assert.True(t, parallel.HasLegalMove(Black))
+
+	for _, start := range randomStarts {
+		b := NewBoardWithVariant(8, Variant{Opening: OpeningRandom, Start: start})
+		totalB, totalW := b.Count()
+
+		assert.Equal(t, start, b.Variant.Start)
+		assert.Equal(t, 2, totalB)
+		assert.Equal(t, 2, totalW)
+		assert.True(t, b.HasLegalMove(Black), start)
+	}
+
+	// the random start is chosen again for the next game
+	random := NewBoardWithVariant(8, Variant{Opening: OpeningRandom})
+	assert.Contains(t, randomStarts, random.Variant.Start)
+	random.Replay()
+	assert.Contains(t, randomStarts, random.Variant.Start)
+}
+
+func TestBoardFreeOpening(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoardWithVariant(8, Variant{Opening: OpeningFree})
+	assert.True(t, b.InSetup())
+	assert.Equal(t, 64, b.CountEmptyCells())
+
+	// only the centre cells until the four discs are placed
+	legal := legalPositions(b)
+	assert.Equal(t, []Position{{3, 3}, {4, 3}, {3, 4}, {4, 4}}, legal)
+	assert.Contains(t, legal, NewAiPlayerWithLevel(8, 3).getPosition(b))
+
+	var err error
+	for _, p := range []Position{{3, 3}, {4, 3}, {3, 4}, {4, 4}} {
+		b, err = b.Place(p)
+		assert.NoError(t, err)
+	}
+
+	assert.False(t, b.InSetup())
+	assert.Equal(t, Black, b.Turn)
+	assert.Equal(t, []string{"---XO---", "---XO---"}, boardRows(b)[3:5])
+
+	// the usual rules after the setup
+	_, err = b.Place(Position{3, 3})
+	assert.Error(t, err)
+	assert.True(t, slices.Contains(legalPositions(b), Position{5, 3}))
+}
+
+func TestBoardResultAnti(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	cells := [][]string{
+		{"w", "w", "w"},
+		{"w", "b", "w"},
+		{"w", "w", "w"},
+	}
+
+	b := NewBoard(3)
+	b.FromStringCells(cells)
+	assert.Equal(t, -7, b.Result())
+
+	anti := NewBoardWithVariant(3, Variant{Rule: RuleAnti})
+	anti.FromStringCells(cells)
+	assert.Equal(t, 7, anti.Result())
+
+	// black wins the playout with fewer discs
+	winner, draw := newSeededMctsPlayer(3, 1).playout(anti)
+	assert.Equal(t, Black, winner)
+	assert.False(t, draw)
+}
+
+func TestAiEvaluationAnti(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoard(6)
+	b, _ = b.Place(Position{3, 1})
+
+	anti := NewBoardWithVariant(6, Variant{Rule: RuleAnti})
+	anti, _ = anti.Place(Position{3, 1})
+
+	ap := NewAiPlayer(6)
+
+	assert.NotZero(t, ap.evaluate(b))
+	assert.Equal(t, -ap.evaluate(b), ap.evaluate(anti))
+	assert.Equal(t, -ap.evaluateFinalBoard(b), ap.evaluateFinalBoard(anti))
+}
+
+func TestGameResultAnti(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewBoardWithVariant(3, Variant{Rule: RuleAnti})
+	b.FromStringCells(
+		[][]string{
+			{"w", "w", "w"},
+			{"w", "b", "w"},
+			{"w", "w", "w"},
+		},
+	)
+
+	g := NewGame(b, Human, Human)
+	g.finish()
+
+	assert.Equal(t, "Black 1, White 8, Player 1 won ✨", g.Message)
+}
+
+func TestGameStartMessageVariant(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	g := NewGame(NewBoard(8), Human, Human)
+	assert.Equal(t, messageGameStart, g.startMessage())
+
+	g = NewGame(NewBoardWithVariant(8, Variant{Rule: RuleAnti, Opening: OpeningFree}), Human, Human)
+	assert.Equal(t, "💫  Game Start! (Anti-reversi, free opening)\nPlace the first four discs on the centre.", g.startMessage())
+}
+
+func TestRecordVariant(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	g := NewGame(NewBoardWithVariant(8, Variant{Opening: OpeningFree}), Human, Human)
+	for _, p := range []Position{{3, 3}, {4, 3}, {3, 4}, {4, 4}, {5, 3}} {
+		g.place(p)
+	}
+
+	r := g.Record()
+	assert.Equal(t, Variant{Rule: RuleStandard, Opening: OpeningFree}, r.Variant)
+	assert.Equal(t, []string{"d4", "e4", "d5", "e5", "f4"}, r.Moves)
+
+	// replayed from the empty board
+	boards, _, err := r.Positions()
+	assert.NoError(t, err)
+	assert.Equal(t, boardRows(g.Board), boardRows(boards[len(boards)-1]))
+
+	// the standard rules are not saved
+	standard := NewGame(NewBoard(8), Human, Human)
+	assert.Equal(t, Variant{}, standard.Record().Variant)
+
+	_, _, err = Record{N: 8, Variant: Variant{Opening: OpeningRandom, Start: "bbbw"}}.Positions()
+	assert.EqualError(t, err, `Invalid variant: Unknown start "bbbw": it must be 2 b and 2 w like bwwb`)
+}
+
+func TestParseVariant(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	v, err := parseVariant("anti", "parallel")
+	assert.NoError(t, err)
+	assert.Equal(t, Variant{Rule: RuleAnti, Opening: OpeningParallel}, v)
+
+	_, err = parseVariant("reverse", "standard")
+	assert.EqualError(t, err, `Unknown rule "reverse": it must be standard or anti`)
+
+	_, err = parseVariant("standard", "cross")
+	assert.EqualError(t, err, `Unknown opening "cross": it must be standard, parallel, random or free`)
+}
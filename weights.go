@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Weights is the score of each cell used by the evaluation, for each board size.
+// It's saved as JSON like {"6": [[30, -12, ...], ...]}
+type Weights map[int][][]int
+
+// aiWeights replaces the compiled-in cell scores of the sizes it has. Loaded from weights_file in the config
+var aiWeights Weights
+
+// LoadWeights reads a weights file. A file of only rows, e.g. [[30, -12, ...], ...], is the table for its size
+func LoadWeights(path string) (Weights, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read weights: %w", err)
+	}
+
+	weights := make(Weights)
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var rows [][]int
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return nil, fmt.Errorf("Failed to parse weights %s: %w", path, err)
+		}
+		weights[len(rows)] = rows
+	} else if err := json.Unmarshal(data, &weights); err != nil {
+		return nil, fmt.Errorf("Failed to parse weights %s: %w", path, err)
+	}
+
+	if err := weights.validate(); err != nil {
+		return nil, fmt.Errorf("Invalid weights %s: %w", path, err)
+	}
+
+	return weights, nil
+}
+
+// loadWeightsOrEmpty reads the weights file if it exists, so that a new table can be added to it
+func loadWeightsOrEmpty(path string) (Weights, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return make(Weights), nil
+	}
+
+	return LoadWeights(path)
+}
+
+func (w Weights) validate() error {
+	for n, rows := range w {
+		if n < MIN_N || n > MAX_N {
+			return fmt.Errorf("board size must be between %d and %d: %d", MIN_N, MAX_N, n)
+		}
+
+		if len(rows) != n {
+			return fmt.Errorf("the table for %dx%d must have %d rows", n, n, n)
+		}
+
+		for y, row := range rows {
+			if len(row) != n {
+				return fmt.Errorf("row %d of the table for %dx%d must have %d cells", y+1, n, n, n)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Save writes the weights with a row on each line
+func (w Weights) Save(path string) error {
+	sizes := make([]int, 0, len(w))
+	for n := range w {
+		sizes = append(sizes, n)
+	}
+	sort.Ints(sizes)
+
+	var builder strings.Builder
+	builder.WriteString("{\n")
+
+	for i, n := range sizes {
+		fmt.Fprintf(&builder, "  \"%d\": [\n", n)
+
+		for y, row := range w[n] {
+			cells := make([]string, 0, len(row))
+			for _, score := range row {
+				cells = append(cells, fmt.Sprintf("%4d", score))
+			}
+
+			fmt.Fprintf(&builder, "    [%s]", strings.Join(cells, ","))
+			if y < len(w[n])-1 {
+				builder.WriteString(",")
+			}
+			builder.WriteString("\n")
+		}
+
+		builder.WriteString("  ]")
+		if i < len(sizes)-1 {
+			builder.WriteString(",")
+		}
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString("}\n")
+
+	if err := os.WriteFile(path, []byte(builder.String()), 0644); err != nil {
+		return fmt.Errorf("Failed to save weights: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightsSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.json")
+	weights := Weights{
+		3: {{1, 2, 1}, {2, -3, 2}, {1, 2, 1}},
+		4: {{20, -5, -5, 20}, {-5, -1, -1, -5}, {-5, -1, -1, -5}, {20, -5, -5, 20}},
+	}
+
+	assert.NoError(t, weights.Save(path))
+
+	loaded, err := LoadWeights(path)
+	assert.NoError(t, err)
+	assert.Equal(t, weights, loaded)
+
+	bytes, _ := os.ReadFile(path)
+	assert.Contains(t, string(bytes), "    [   1,   2,   1],\n")
+}
+
+func TestLoadWeightsRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.json")
+	os.WriteFile(path, []byte(`[[1, 2, 1], [2, 0, 2], [1, 2, 1]]`), 0644)
+
+	weights, err := LoadWeights(path)
+
+	assert.NoError(t, err)
+	assert.Equal(t, Weights{3: {{1, 2, 1}, {2, 0, 2}, {1, 2, 1}}}, weights)
+}
+
+func TestLoadWeightsInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	cases := map[string]string{
+		`{"4": [[1, 2, 3, 4]]}`:           "the table for 4x4 must have 4 rows",
+		`{"3": [[1, 2], [1, 2], [1, 2]]}`: "row 1 of the table for 3x3 must have 3 cells",
+		`{"11": []}`:                      "board size must be between 3 and 10: 11",
+	}
+
+	for content, want := range cases {
+		path := filepath.Join(dir, "weights.json")
+		os.WriteFile(path, []byte(content), 0644)
+
+		_, err := LoadWeights(path)
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestLoadWeightsOrEmpty(t *testing.T) {
+	weights, err := loadWeightsOrEmpty(filepath.Join(t.TempDir(), "missing.json"))
+
+	assert.NoError(t, err)
+	assert.Empty(t, weights)
+}
+
+func TestGenerateCellScores(t *testing.T) {
+	table := generateCellScores(6, 4)
+
+	assert.Equal(t, [][]int{
+		{30, -12, 0, 0, -12, 30},
+		{-12, -15, -3, -3, -15, -12},
+		{-12, -15, -3, -3, -15, -12},
+		{30, -12, 0, 0, -12, 30},
+	}, table)
+
+	assert.Len(t, cellScores(10, 6), 6)
+	assert.Len(t, cellScores(10, 6)[0], 10)
+	assert.Equal(t, generateCellScores(10, 10), cellScores(10, 10))
+}
+
+func TestCellScoresFromWeights(t *testing.T) {
+	defer func() { aiWeights = nil }()
+
+	// a tuned table is used for the large square boards too, instead of the generated one
+	table := generateCellScores(10, 10)
+	table[0][0] = 999
+	aiWeights = Weights{10: table}
+
+	assert.Equal(t, 999, cellScores(10, 10)[0][0])
+	assert.Equal(t, 30, cellScores(10, 6)[0][0])
+}
+
+func TestAiPlayerForKeepsTables(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+	resetAiPlayers()
+
+	b := NewRectBoard(10, 6, Variant{})
+	first := newAiPlayerFor(b)
+	second := newAiPlayerFor(b)
+
+	// the tables are built once, but each search has its own player
+	assert.NotSame(t, first, second)
+	assert.Same(t, &first.ScoreTable[0], &second.ScoreTable[0])
+
+	first.Analyze(b, 2, 0)
+	assert.Nil(t, second.killers)
+
+	// another size has its own tables
+	assert.Equal(t, 8, newAiPlayerFor(NewBoard(8)).W)
+
+	resetAiPlayers()
+	assert.NotSame(t, &first.ScoreTable[0], &newAiPlayerFor(b).ScoreTable[0])
+}
+
+func TestRectAiPlayer(t *testing.T) {
+	logger = NewLogger(slog.LevelInfo)
+
+	b := NewRectBoard(10, 6, Variant{})
+	ap := NewRectAiPlayer(10, 6, 3)
+
+	// the board is symmetrical at the start
+	assert.Equal(t, 0, ap.evaluate(b))
+
+	for i := 0; i < 6 && b.HasLegalMove(b.Turn); i++ {
+		p := ap.getPosition(b)
+		assert.True(t, b.IsLegal(p.X+p.Y*b.W, b.Turn), "%s", p)
+		b, _ = b.Place(p)
+	}
+}